@@ -0,0 +1,208 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// AuditConfig configures the Audit middleware.
+	AuditConfig struct {
+		// Output receives one JSON line per request, the standard output by default.
+		Output io.Writer
+		// MaxBodySize is the number of bytes of each body recorded, 4KB by default.
+		MaxBodySize int
+		// RedactFields lists the JSON keys, form fields, query parameters, XML elements and
+		// attributes, and name=value or name: value pairs of text bodies whose value is
+		// replaced by "[REDACTED]", case-insensitively. It defaults to password, token,
+		// access_token, refresh_token, secret and api_key.
+		RedactFields []string
+	}
+
+	// auditWriter records the beginning of the response while passing it through.
+	auditWriter struct {
+		ResponseWriter
+		body  bytes.Buffer
+		limit int
+	}
+
+	// auditBody reads the peeked request body then the rest, and closes the original body.
+	auditBody struct {
+		io.Reader
+		io.Closer
+	}
+
+	auditEntry struct {
+		Time          time.Time `json:"time"`
+		Method        string    `json:"method"`
+		Path          string    `json:"path"`
+		Query         string    `json:"query,omitempty"`
+		Status        int       `json:"status"`
+		Latency       float64   `json:"latency"`
+		ClientIP      string    `json:"client_ip"`
+		RequestID     string    `json:"request_id,omitempty"`
+		RequestBody   string    `json:"request_body,omitempty"`
+		RequestSize   int64     `json:"request_size"`
+		ResponseBody  string    `json:"response_body,omitempty"`
+		ResponseSize  int       `json:"response_size"`
+		BodyTruncated bool      `json:"body_truncated,omitempty"`
+	}
+)
+
+const redacted = "[REDACTED]"
+
+var defaultRedactFields = []string{"password", "token", "access_token", "refresh_token", "secret", "api_key"}
+
+// Audit returns a middleware recording the requests with the beginning of their request and
+// response bodies, after redacting the sensitive fields. Only the bodies of text, JSON, XML
+// and form content types are recorded. The request body is peeked and the response copied
+// as it is written, so neither is held back and streamed responses still flush.
+func Audit(config AuditConfig) HandlerFunc {
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 4096
+	}
+	if len(config.RedactFields) == 0 {
+		config.RedactFields = defaultRedactFields
+	}
+	fields := make(map[string]bool, len(config.RedactFields))
+	quoted := make([]string, len(config.RedactFields))
+	for i, field := range config.RedactFields {
+		fields[strings.ToLower(field)] = true
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	// redactJSON catches the fields of the JSON bodies that don't parse, e.g. truncated ones.
+	redactJSON := regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\s]*)`)
+	// redactElement, redactAttr and redactPair catch the fields of the XML and text bodies,
+	// the element content running up to the next tag or the end of a truncated body.
+	names := `(?:[\w.-]+:)?(?:` + strings.Join(quoted, "|") + `)`
+	redactElement := regexp.MustCompile(`(?i)(<` + names + `(?:\s[^>]*)?>)[^<]*`)
+	redactAttr := regexp.MustCompile(`(?i)(\s` + names + `\s*=\s*)("[^"]*"?|'[^']*'?)`)
+	redactPair := regexp.MustCompile(`(?i)(\b(?:` + strings.Join(quoted, "|") + `)\s*[=:]\s*)[^\s&;,<"']+`)
+	redact := func(contentType string, body []byte) string {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch {
+		case strings.HasSuffix(mediaType, "json"):
+			var v interface{}
+			if json.Unmarshal(body, &v) == nil {
+				redactValue(v, fields)
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+			return redactJSON.ReplaceAllString(string(body), `${1}"`+redacted+`"`)
+		case mediaType == MIMEPOSTForm:
+			return redactQuery(string(body), fields)
+		case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "xml"):
+			text := redactElement.ReplaceAllString(string(body), "${1}"+redacted)
+			text = redactAttr.ReplaceAllString(text, `${1}"`+redacted+`"`)
+			return redactPair.ReplaceAllString(text, "${1}"+redacted)
+		}
+		return ""
+	}
+	var mu sync.Mutex
+
+	return func(c *Context) {
+		start := time.Now()
+		entry := auditEntry{
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Query:       redactQuery(c.Request.URL.RawQuery, fields),
+			RequestSize: c.Request.ContentLength,
+		}
+
+		var requestBody []byte
+		if c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(config.MaxBodySize)+1))
+			c.Request.Body = auditBody{io.MultiReader(bytes.NewReader(requestBody), c.Request.Body), c.Request.Body}
+			if len(requestBody) > config.MaxBodySize {
+				requestBody = requestBody[:config.MaxBodySize]
+				entry.BodyTruncated = true
+			}
+		}
+
+		original := c.Writer
+		w := &auditWriter{ResponseWriter: original, limit: config.MaxBodySize}
+		c.Writer = w
+		defer func() { c.Writer = original }()
+
+		c.Next()
+
+		entry.Time = start
+		entry.Latency = time.Since(start).Seconds()
+		entry.Status = w.Status()
+		entry.ClientIP = c.ClientIP()
+		entry.RequestID = c.RequestID()
+		entry.RequestBody = redact(c.Request.Header.Get("Content-Type"), requestBody)
+		entry.ResponseBody = redact(w.Header().Get("Content-Type"), w.body.Bytes())
+		if entry.ResponseSize = w.Size(); entry.ResponseSize > config.MaxBodySize {
+			entry.BodyTruncated = true
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			c.ErrorTyped(err, ErrorTypeInternal, "audit")
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		config.Output.Write(append(line, '\n'))
+	}
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	if room := w.limit - w.body.Len(); room > 0 {
+		if room > len(data) {
+			room = len(data)
+		}
+		w.body.Write(data[:room])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// redactValue replaces the values of the sensitive keys of a decoded JSON value.
+func redactValue(v interface{}, fields map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if fields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				redactValue(value, fields)
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			redactValue(value, fields)
+		}
+	}
+}
+
+// redactQuery replaces the values of the sensitive fields of a query string or form body.
+func redactQuery(query string, fields map[string]bool) string {
+	if query == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return redacted
+	}
+	for key := range values {
+		if fields[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+		}
+	}
+	return values.Encode()
+}
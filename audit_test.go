@@ -0,0 +1,91 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestAudit tests that bodies are recorded with their sensitive fields redacted.
+func TestAudit(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(Audit(AuditConfig{Output: output, MaxBodySize: 64}))
+	r.POST("/login", func(c *Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if !strings.Contains(string(body), "hunter2") {
+			t.Errorf("Handler should read the whole body, was: %s", body)
+		}
+		c.JSON(200, H{"token": "abc", "user": "manu"})
+	})
+
+	body := `{"user":"manu","password":"hunter2","profile":{"api_key":"k"}}`
+	req, _ := http.NewRequest("POST", "/login?token=t&page=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEJSON)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("Audit entry should be JSON, was: %s", output.String())
+	}
+	line := output.String()
+	if strings.Contains(line, "hunter2") || strings.Contains(line, `"k"`) || strings.Contains(line, "abc") || strings.Contains(line, "token=t") {
+		t.Errorf("Sensitive fields should be redacted, was: %s", line)
+	}
+	if !strings.Contains(entry["request_body"].(string), `"user":"manu"`) || !strings.Contains(entry["response_body"].(string), `"user":"manu"`) {
+		t.Errorf("Bodies should be recorded, was: %s", line)
+	}
+	if entry["status"].(float64) != 200 || entry["path"] != "/login" {
+		t.Errorf("Request should be recorded, was: %s", line)
+	}
+}
+
+// TestAuditTruncated tests that truncated JSON bodies are still redacted.
+func TestAuditTruncated(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(Audit(AuditConfig{Output: output, MaxBodySize: 32}))
+	r.POST("/", func(c *Context) {})
+
+	body := `{"password":"hunter2","comment":"` + strings.Repeat("x", 100) + `"}`
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEJSON)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if line := output.String(); strings.Contains(line, "hunter2") || !strings.Contains(line, `"body_truncated":true`) {
+		t.Errorf("Truncated body should be redacted, was: %s", line)
+	}
+}
+
+// TestAuditXML tests that the XML and text bodies are redacted.
+func TestAuditXML(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(Audit(AuditConfig{Output: output}))
+	r.POST("/", func(c *Context) {
+		c.String(200, "ok, token=abc")
+	})
+
+	body := `<login api_key="k1" user="manu"><password>hunter2</password><ns:Token>t1</ns:Token></login>`
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEXML)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := output.String()
+	for _, secret := range []string{"hunter2", "k1", "t1", "abc"} {
+		if strings.Contains(line, secret) {
+			t.Errorf("Sensitive fields should be redacted, was: %s", line)
+		}
+	}
+	if !strings.Contains(line, `user=\"manu\"`) || !strings.Contains(line, "ok, token=") {
+		t.Errorf("Bodies should be recorded, was: %s", line)
+	}
+}
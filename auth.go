@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 )
 
 const (
@@ -42,11 +43,7 @@ func BasicAuthForRealm(accounts Accounts, realm string) HandlerFunc {
 		user, ok := searchCredential(pairs, c.Request.Header.Get("Authorization"))
 		if !ok {
 			// Credentials doesn't match, we return 401 Unauthorized and abort request.
-			if realm == "" {
-				realm = "Authorization Required"
-			}
-			c.Writer.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=\"%s\"", realm))
-			c.Fail(401, errors.New("Unauthorized"))
+			challenge(c, realm)
 		} else {
 			// user is allowed, set UserId to key "user" in this context, the userId can be read later using
 			// c.Get(gin.AuthUserKey)
@@ -61,6 +58,36 @@ func BasicAuth(accounts Accounts) HandlerFunc {
 	return BasicAuthForRealm(accounts, "")
 }
 
+// BasicAuthFunc implements Basic HTTP Authorization against an account store: lookup returns the
+// password of a user, and false for unknown users. The password is compared in constant time.
+// The authenticated user is set to the AuthUserKey key of the context, and failed attempts get
+// a 401 with a WWW-Authenticate challenge for realm ("Authorization Required" when empty).
+func BasicAuthFunc(lookup func(user string) (password string, ok bool), realm string) HandlerFunc {
+	return func(c *Context) {
+		user, password, ok := c.Request.BasicAuth()
+		if ok && user != "" {
+			expected, found := lookup(user)
+			// compare even for unknown users, so the timing doesn't tell them apart
+			match := secureCompare(password, expected)
+			if found && match {
+				c.Set(AuthUserKey, user)
+				return
+			}
+		}
+		challenge(c, realm)
+	}
+}
+
+// challenge aborts with 401 Unauthorized and asks for Basic credentials for realm.
+func challenge(c *Context, realm string) {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	realm = strings.Replace(strings.Replace(realm, `\`, `\\`, -1), `"`, `\"`, -1)
+	c.Writer.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=\"%s\"", realm))
+	c.Fail(401, errors.New("Unauthorized"))
+}
+
 func processAccounts(accounts Accounts) (authPairs, error) {
 	if len(accounts) == 0 {
 		return nil, errors.New("Empty list of authorized credentials")
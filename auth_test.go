@@ -27,7 +27,7 @@ func TestBasicAuthSucceed(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 200 {
-		t.Errorf("Response code should be Ok, was: %s", w.Code)
+		t.Errorf("Response code should be Ok, was: %d", w.Code)
 	}
 	bodyAsString := w.Body.String()
 
@@ -52,7 +52,7 @@ func TestBasicAuth401(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 401 {
-		t.Errorf("Response code should be Not autorized, was: %s", w.Code)
+		t.Errorf("Response code should be Not autorized, was: %d", w.Code)
 	}
 
 	if w.HeaderMap.Get("WWW-Authenticate") != "Basic realm=\"Authorization Required\"" {
@@ -76,10 +76,40 @@ func TestBasicAuth401WithCustomRealm(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 401 {
-		t.Errorf("Response code should be Not autorized, was: %s", w.Code)
+		t.Errorf("Response code should be Not autorized, was: %d", w.Code)
 	}
 
 	if w.HeaderMap.Get("WWW-Authenticate") != "Basic realm=\"My Custom Realm\"" {
 		t.Errorf("WWW-Authenticate header is incorrect: %s", w.HeaderMap.Get("Content-Type"))
 	}
 }
+
+func TestBasicAuthFunc(t *testing.T) {
+	users := map[string]string{"admin": "secret"}
+	r := New()
+	r.Use(BasicAuthFunc(func(user string) (string, bool) {
+		password, ok := users[user]
+		return password, ok
+	}, "Internal"))
+	r.GET("/login", func(c *Context) {
+		c.String(200, c.MustGet(AuthUserKey).(string))
+	})
+
+	perform := func(user, password string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/login", nil)
+		req.SetBasicAuth(user, password)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := perform("admin", "secret"); w.Code != 200 || w.Body.String() != "admin" {
+		t.Errorf("Valid credentials should be accepted, was: %d %s", w.Code, w.Body.String())
+	}
+	for _, credentials := range [][2]string{{"admin", "wrong"}, {"nobody", "secret"}, {"", ""}} {
+		w := perform(credentials[0], credentials[1])
+		if w.Code != 401 || w.HeaderMap.Get("WWW-Authenticate") != `Basic realm="Internal"` {
+			t.Errorf("Invalid credentials %v should be challenged, was: %d %s", credentials, w.Code, w.HeaderMap.Get("WWW-Authenticate"))
+		}
+	}
+}
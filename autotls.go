@@ -0,0 +1,68 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build autocert
+// +build autocert
+
+package gin
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"third/crypto/acme/autocert"
+)
+
+// AutoTLSConfig configures RunAutoTLSWithConfig.
+type AutoTLSConfig struct {
+	// Domains lists the host names certificates are requested for.
+	Domains []string
+	// Email is the contact address of the ACME account, optional.
+	Email string
+	// CacheDir keeps the account key and the certificates across restarts,
+	// gin-autocert in the user cache directory by default.
+	CacheDir string
+	// RedirectHTTP redirects the HTTP requests other than the ACME challenges to HTTPS,
+	// instead of serving them.
+	RedirectHTTP bool
+}
+
+// RunAutoTLS serves HTTPS on :443 with certificates obtained and renewed from Let's Encrypt
+// for domains, and the ACME HTTP-01 challenges on :80, redirecting the other requests to HTTPS.
+// It is only available when building with the autocert tag, which needs third/crypto/acme/autocert.
+func (engine *Engine) RunAutoTLS(domains ...string) error {
+	return engine.RunAutoTLSWithConfig(AutoTLSConfig{Domains: domains, RedirectHTTP: true})
+}
+
+// RunAutoTLSWithConfig is like RunAutoTLS with the settings of config.
+func (engine *Engine) RunAutoTLSWithConfig(config AutoTLSConfig) error {
+	if config.CacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return err
+		}
+		config.CacheDir = filepath.Join(dir, "gin-autocert")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Cache:      autocert.DirCache(config.CacheDir),
+		Email:      config.Email,
+	}
+
+	var fallback http.Handler = engine
+	if config.RedirectHTTP {
+		fallback = redirectToHTTPS("")
+	}
+	srv := engine.newServer(":80")
+	srv.Handler = m.HTTPHandler(fallback)
+	go func() {
+		if err := engine.serve(srv, srv.ListenAndServe); err != nil {
+			log.Printf("gin: ACME challenge server on :80: %v", err)
+		}
+	}()
+	defer srv.Close()
+	return engine.RunTLSConfig(":443", m.TLSConfig())
+}
@@ -5,13 +5,16 @@
 package binding
 
 import (
-	"encoding/json"
 	"encoding/xml"
-	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"reflect"
 	"strconv"
 	"strings"
+	"third/gin/codec/json"
+	"time"
 )
 
 type (
@@ -19,8 +22,16 @@ type (
 		Bind(*http.Request, interface{}) error
 	}
 
+	// BindingUri binds the path parameters of the matched route.
+	BindingUri interface {
+		BindUri(map[string][]string, interface{}) error
+	}
+
 	// JSON binding
-	jsonBinding struct{}
+	jsonBinding struct {
+		useNumber             bool
+		disallowUnknownFields bool
+	}
 
 	// XML binding
 	xmlBinding struct{}
@@ -28,8 +39,19 @@ type (
 	// form binding
 	formBinding struct{}
 
+	// query string binding
+	queryBinding struct{}
+
+	// path parameters binding
+	uriBinding struct{}
+
+	// request headers binding
+	headerBinding struct{}
+
 	// multipart form binding
-	multipartFormBinding struct{}
+	multipartFormBinding struct {
+		maxMemory int64
+	}
 )
 
 const MAX_MEMORY = 1 * 1024 * 1024
@@ -38,11 +60,27 @@ var (
 	JSON          = jsonBinding{}
 	XML           = xmlBinding{}
 	Form          = formBinding{} // todo
-	MultipartForm = multipartFormBinding{}
+	Query         = queryBinding{}
+	Uri           = uriBinding{}
+	Header        = headerBinding{}
+	MultipartForm = multipartFormBinding{maxMemory: MAX_MEMORY}
+
+	// StrictJSON rejects payloads with fields unknown to the target struct and decodes
+	// numbers held in interface{} values as json.Number, so large int64 IDs aren't truncated.
+	StrictJSON = jsonBinding{useNumber: true, disallowUnknownFields: true}
 )
 
-func (_ jsonBinding) Bind(req *http.Request, obj interface{}) error {
-	decoder := json.NewDecoder(req.Body)
+func (b jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := setDefaults(obj); err != nil {
+		return err
+	}
+	decoder := json.API.NewDecoder(req.Body)
+	if b.useNumber {
+		decoder.UseNumber()
+	}
+	if b.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
 	if err := decoder.Decode(obj); err == nil {
 		return Validate(obj)
 	} else {
@@ -51,6 +89,9 @@ func (_ jsonBinding) Bind(req *http.Request, obj interface{}) error {
 }
 
 func (_ xmlBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := setDefaults(obj); err != nil {
+		return err
+	}
 	decoder := xml.NewDecoder(req.Body)
 	if err := decoder.Decode(obj); err == nil {
 		return Validate(obj)
@@ -69,51 +110,375 @@ func (_ formBinding) Bind(req *http.Request, obj interface{}) error {
 	return Validate(obj)
 }
 
-func (_ multipartFormBinding) Bind(req *http.Request, obj interface{}) error {
-	if err := req.ParseMultipartForm(MAX_MEMORY); err != nil {
+// Bind maps the URL query string only, whatever the method and the body are.
+func (_ queryBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := mapForm(obj, req.URL.Query()); err != nil {
 		return err
 	}
-	if err := mapForm(obj, req.Form); err != nil {
+	return Validate(obj)
+}
+
+// BindUri maps path parameters into the fields tagged with `uri:"name"`.
+func (_ uriBinding) BindUri(params map[string][]string, obj interface{}) error {
+	if err := mapFormByTag(obj, formValues(params), "uri"); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// Bind maps the request headers into the fields tagged with `header:"X-Name"`.
+// Header names are matched case-insensitively.
+func (_ headerBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := mapFormByTag(obj, headerValues(req.Header), "header"); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// MultipartFormMemory returns the multipart form binding keeping up to maxMemory bytes of the
+// form in memory, the rest going to temporary files. MultipartForm keeps MAX_MEMORY bytes.
+func MultipartFormMemory(maxMemory int64) Binding {
+	return multipartFormBinding{maxMemory: maxMemory}
+}
+
+// Bind maps the form values as well as the uploaded files, into *multipart.FileHeader
+// and []*multipart.FileHeader fields.
+func (b multipartFormBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseMultipartForm(b.maxMemory); err != nil {
+		return err
+	}
+	if err := mapFormByTag(obj, multipartValues{formValues(req.Form), req.MultipartForm}, "form"); err != nil {
 		return err
 	}
 	return Validate(obj)
 }
 
+// formSource returns the raw values bound to a tag name.
+type formSource interface {
+	lookup(key string) ([]string, bool)
+	each(fn func(key string, values []string))
+}
+
+type formValues map[string][]string
+
+func (form formValues) lookup(key string) ([]string, bool) {
+	values, ok := form[key]
+	return values, ok
+}
+
+func (form formValues) each(fn func(key string, values []string)) {
+	for key, values := range form {
+		fn(key, values)
+	}
+}
+
+// headerValues matches tag names case-insensitively, as http.Header keys are canonicalized.
+type headerValues map[string][]string
+
+func (header headerValues) lookup(key string) ([]string, bool) {
+	values, ok := header[textproto.CanonicalMIMEHeaderKey(key)]
+	return values, ok
+}
+
+func (header headerValues) each(fn func(key string, values []string)) {
+	for key, values := range header {
+		fn(key, values)
+	}
+}
+
+// fileSource is implemented by the sources that also carry uploaded files.
+type fileSource interface {
+	lookupFiles(key string) ([]*multipart.FileHeader, bool)
+}
+
+type multipartValues struct {
+	formValues
+	form *multipart.Form
+}
+
+func (m multipartValues) lookupFiles(key string) ([]*multipart.FileHeader, bool) {
+	if m.form == nil || m.form.File == nil {
+		return nil, false
+	}
+	files, ok := m.form.File[key]
+	return files, ok
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
 func mapForm(ptr interface{}, form map[string][]string) error {
-	typ := reflect.TypeOf(ptr).Elem()
-	formStruct := reflect.ValueOf(ptr).Elem()
+	return mapFormByTag(ptr, formValues(form), "form")
+}
+
+func mapFormByTag(ptr interface{}, form formSource, tag string) error {
+	if err := setDefaults(ptr); err != nil {
+		return err
+	}
+	val := reflect.ValueOf(ptr).Elem()
+	if val.Kind() == reflect.Map {
+		return mapMap(val, form)
+	}
+	_, err := mapStruct(val, form, tag, "")
+	return err
+}
+
+// mapMap copies every value of the form into a map[string]string, keeping the first
+// value of each key, or into a map[string][]string.
+func mapMap(val reflect.Value, form formSource) error {
+	typ := val.Type()
+	if typ.Key().Kind() != reflect.String {
+		return fmt.Errorf("cannot bind into %s, map keys must be strings", typ)
+	}
+	elemType := typ.Elem()
+	multiple := elemType.Kind() == reflect.Slice && elemType.Elem().Kind() == reflect.String
+	if elemType.Kind() != reflect.String && !multiple {
+		return fmt.Errorf("cannot bind into %s, map values must be string or []string", typ)
+	}
+	if val.IsNil() {
+		val.Set(reflect.MakeMap(typ))
+	}
+	form.each(func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		elem := reflect.New(elemType).Elem()
+		if multiple {
+			elem.Set(reflect.ValueOf(append([]string(nil), values...)).Convert(elemType))
+		} else {
+			elem.SetString(values[0])
+		}
+		val.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), elem)
+	})
+	return nil
+}
+
+// mapStruct binds the tagged fields of val. Nested structs are looked up as "prefix.name",
+// slices of structs as "name[0].field", "name[1].field"... until an index has no value.
+// It reports whether any value was found for val.
+func mapStruct(val reflect.Value, form formSource, tag, prefix string) (bool, error) {
+	typ := val.Type()
+	found := false
 	for i := 0; i < typ.NumField(); i++ {
 		typeField := typ.Field(i)
-		if inputFieldName := typeField.Tag.Get("form"); inputFieldName != "" {
-			structField := formStruct.Field(i)
-			if !structField.CanSet() {
+		inputFieldName := typeField.Tag.Get(tag)
+		if inputFieldName == "" || inputFieldName == "-" {
+			continue
+		}
+		structField := val.Field(i)
+		if !structField.CanSet() {
+			continue
+		}
+		key := prefix + inputFieldName
+
+		fieldType := structField.Type()
+		switch {
+		case fieldType == fileHeaderType || fieldType == fileHeaderSliceType:
+			files, ok := form.(fileSource)
+			if !ok {
 				continue
 			}
-
-			inputValue, exists := form[inputFieldName]
-			if !exists {
+			fileHeaders, exists := files.lookupFiles(key)
+			if !exists || len(fileHeaders) == 0 {
 				continue
 			}
-			numElems := len(inputValue)
-			if structField.Kind() == reflect.Slice && numElems > 0 {
-				sliceOf := structField.Type().Elem().Kind()
-				slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
-				for i := 0; i < numElems; i++ {
-					if err := setWithProperType(sliceOf, inputValue[i], slice.Index(i)); err != nil {
-						return err
-					}
-				}
-				formStruct.Field(i).Set(slice)
+			if fieldType == fileHeaderType {
+				structField.Set(reflect.ValueOf(fileHeaders[0]))
 			} else {
-				if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
+				structField.Set(reflect.ValueOf(fileHeaders))
+			}
+			found = true
+			continue
+
+		case fieldType.Kind() == reflect.Struct && fieldType != timeType:
+			ok, err := mapStruct(structField, form, tag, key+".")
+			if err != nil {
+				return false, err
+			}
+			found = found || ok
+			continue
+
+		case fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct && fieldType.Elem() != timeType:
+			elem := reflect.New(fieldType.Elem())
+			if err := setStructDefaults(elem.Elem()); err != nil {
+				return false, err
+			}
+			ok, err := mapStruct(elem.Elem(), form, tag, key+".")
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				structField.Set(elem)
+				found = true
+			}
+			continue
+
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct && fieldType.Elem() != timeType:
+			slice := reflect.MakeSlice(fieldType, 0, 0)
+			for j := 0; ; j++ {
+				elem := reflect.New(fieldType.Elem()).Elem()
+				if err := setStructDefaults(elem); err != nil {
+					return false, err
+				}
+				ok, err := mapStruct(elem, form, tag, fmt.Sprintf("%s[%d].", key, j))
+				if err != nil {
+					return false, err
+				}
+				if !ok {
+					break
+				}
+				slice = reflect.Append(slice, elem)
+			}
+			if slice.Len() > 0 {
+				structField.Set(slice)
+				found = true
+			}
+			continue
+		}
+
+		inputValue, exists := form.lookup(key)
+		if !exists {
+			continue
+		}
+		found = true
+		numElems := len(inputValue)
+		if structField.Kind() == reflect.Slice && numElems > 0 {
+			sliceOf := fieldType.Elem().Kind()
+			slice := reflect.MakeSlice(fieldType, numElems, numElems)
+			for i := 0; i < numElems; i++ {
+				if err := setWithProperType(sliceOf, inputValue[i], slice.Index(i)); err != nil {
+					return false, err
+				}
+			}
+			structField.Set(slice)
+		} else if fieldType == timeType {
+			if err := setTimeField(inputValue[0], typeField, structField); err != nil {
+				return false, err
+			}
+		} else {
+			if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
+				return false, err
+			}
+		}
+	}
+	return found, nil
+}
+
+// setDefaults assigns the `default:"..."` tag values of ptr, recursing into nested structs.
+// Binders call it before decoding, so only the fields absent from the request keep them.
+// Fields already set, e.g. by an earlier binder when combining the uri, query and body
+// bindings, are left alone. Slice defaults are comma separated.
+func setDefaults(ptr interface{}) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return setStructDefaults(val.Elem())
+}
+
+func setStructDefaults(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		structField := val.Field(i)
+		if !structField.CanSet() {
+			continue
+		}
+		defaultValue, ok := typeField.Tag.Lookup("default")
+		if ok && !structField.IsZero() {
+			continue
+		}
+		if structField.Type() == timeType {
+			if ok {
+				if err := setTimeField(defaultValue, typeField, structField); err != nil {
 					return err
 				}
 			}
+			continue
+		}
+		if !ok {
+			if structField.Kind() == reflect.Struct {
+				if err := setStructDefaults(structField); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if structField.Kind() == reflect.Slice {
+			values := strings.Split(defaultValue, ",")
+			slice := reflect.MakeSlice(structField.Type(), len(values), len(values))
+			for j, v := range values {
+				if err := setWithProperType(slice.Index(j).Kind(), strings.TrimSpace(v), slice.Index(j)); err != nil {
+					return err
+				}
+			}
+			structField.Set(slice)
+			continue
+		}
+		if err := setWithProperType(structField.Kind(), defaultValue, structField); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+
+// setTimeField parses val into a time.Time field. The layout comes from the `time_format` tag
+// (RFC3339 by default, or "unix", "unixmilli", "unixmicro", "unixnano" for timestamps) and
+// the location from `time_utc:"1"` or `time_location:"Asia/Shanghai"`, local time otherwise.
+func setTimeField(val string, structField reflect.StructField, value reflect.Value) error {
+	timeFormat := structField.Tag.Get("time_format")
+	if timeFormat == "" {
+		timeFormat = time.RFC3339
+	}
+	if val == "" {
+		value.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+
+	switch tf := strings.ToLower(timeFormat); tf {
+	case "unix", "unixmilli", "unixmicro", "unixnano":
+		tv, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		var t time.Time
+		switch tf {
+		case "unix":
+			t = time.Unix(tv, 0)
+		case "unixmilli":
+			t = time.Unix(0, tv*int64(time.Millisecond))
+		case "unixmicro":
+			t = time.Unix(0, tv*int64(time.Microsecond))
+		default:
+			t = time.Unix(0, tv)
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	loc := time.Local
+	if isUTC, _ := strconv.ParseBool(structField.Tag.Get("time_utc")); isUTC {
+		loc = time.UTC
+	}
+	if locTag := structField.Tag.Get("time_location"); locTag != "" {
+		l, err := time.LoadLocation(locTag)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation(timeFormat, val, loc)
+	if err != nil {
+		return err
+	}
+	value.Set(reflect.ValueOf(t))
+	return nil
+}
+
 func setIntField(val string, bitSize int, structField reflect.Value) error {
 	if val == "" {
 		val = "0"
@@ -206,79 +571,3 @@ func ensureNotPointer(obj interface{}) {
 		panic("Pointers are not accepted as binding models")
 	}
 }
-
-func Validate(obj interface{}, parents ...string) error {
-	typ := reflect.TypeOf(obj)
-	val := reflect.ValueOf(obj)
-
-	if typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
-		val = val.Elem()
-	}
-
-	switch typ.Kind() {
-	case reflect.Struct:
-		for i := 0; i < typ.NumField(); i++ {
-			field := typ.Field(i)
-
-			// Allow ignored and unexported fields in the struct
-			if len(field.PkgPath) > 0 || field.Tag.Get("form") == "-" {
-				continue
-			}
-
-			fieldValue := val.Field(i).Interface()
-			zero := reflect.Zero(field.Type).Interface()
-
-			if strings.Index(field.Tag.Get("binding"), "required") > -1 {
-				fieldType := field.Type.Kind()
-				if fieldType == reflect.Struct {
-					if reflect.DeepEqual(zero, fieldValue) {
-						return errors.New("Required " + field.Name)
-					}
-					err := Validate(fieldValue, field.Name)
-					if err != nil {
-						return err
-					}
-				} else if reflect.DeepEqual(zero, fieldValue) {
-					if len(parents) > 0 {
-						return errors.New("Required " + field.Name + " on " + parents[0])
-					} else {
-						return errors.New("Required " + field.Name)
-					}
-				} else if fieldType == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
-					err := Validate(fieldValue)
-					if err != nil {
-						return err
-					}
-				}
-			} else {
-				fieldType := field.Type.Kind()
-				if fieldType == reflect.Struct {
-					if reflect.DeepEqual(zero, fieldValue) {
-						continue
-					}
-					err := Validate(fieldValue, field.Name)
-					if err != nil {
-						return err
-					}
-				} else if fieldType == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
-					err := Validate(fieldValue, field.Name)
-					if err != nil {
-						return err
-					}
-				}
-			}
-		}
-	case reflect.Slice:
-		for i := 0; i < val.Len(); i++ {
-			fieldValue := val.Index(i).Interface()
-			err := Validate(fieldValue)
-			if err != nil {
-				return err
-			}
-		}
-	default:
-		return nil
-	}
-	return nil
-}
@@ -0,0 +1,51 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type (
+	// FieldError describes a struct field that failed a validation rule.
+	FieldError struct {
+		// Namespace is the dotted path of the field from the bound object, e.g. "Items[0].SKU".
+		Namespace string `json:"field"`
+		// Field is the Go name of the field and Struct the name of the field holding it, if any.
+		Field  string `json:"-"`
+		Struct string `json:"-"`
+		// Tag is the violated rule and Param its parameter, e.g. "eqfield" and "Password".
+		Tag   string `json:"rule"`
+		Param string `json:"param,omitempty"`
+		// Value is the rejected value. It isn't serialized, as it may be a password or a token.
+		Value interface{} `json:"-"`
+	}
+
+	// ValidationErrors is returned by the default validator and lists every invalid field.
+	// It can be rendered as is with c.JSON.
+	ValidationErrors []*FieldError
+)
+
+func (e *FieldError) Error() string {
+	if e.Tag == "required" {
+		if len(e.Struct) > 0 {
+			return "Required " + e.Field + " on " + e.Struct
+		}
+		return "Required " + e.Field
+	}
+	return fmt.Sprintf("Field validation for '%s' failed on the '%s' tag", e.Field, e.Tag)
+}
+
+func (errs ValidationErrors) Error() string {
+	var buffer bytes.Buffer
+	for i, err := range errs {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(err.Error())
+	}
+	return buffer.String()
+}
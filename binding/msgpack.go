@@ -0,0 +1,36 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build msgpack
+// +build msgpack
+
+package binding
+
+import (
+	"net/http"
+	"third/msgpack"
+)
+
+// MessagePack binding
+type msgpackBinding struct{}
+
+// MsgPack decodes the body as MessagePack, for the application/x-msgpack and
+// application/msgpack content types. It is only available when building with the msgpack
+// tag, which needs third/msgpack.
+var MsgPack = msgpackBinding{}
+
+func init() {
+	Register("application/x-msgpack", MsgPack)
+	Register("application/msgpack", MsgPack)
+}
+
+func (_ msgpackBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := setDefaults(obj); err != nil {
+		return err
+	}
+	if err := msgpack.NewDecoder(req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
@@ -0,0 +1,40 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build protobuf
+// +build protobuf
+
+package binding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"third/protobuf/proto"
+)
+
+// Protocol Buffers binding
+type protobufBinding struct{}
+
+// ProtoBuf decodes the body as Protocol Buffers, for the application/x-protobuf content type.
+// It is only available when building with the protobuf tag, which needs third/protobuf.
+var ProtoBuf = protobufBinding{}
+
+func init() {
+	Register("application/x-protobuf", ProtoBuf)
+}
+
+// Bind decodes the body into obj, which must be a proto.Message.
+// Generated messages can't carry `binding` tags, so no validation is run.
+func (_ protobufBinding) Bind(req *http.Request, obj interface{}) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return errors.New("obj is not a proto.Message")
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
+}
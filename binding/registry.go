@@ -0,0 +1,42 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Binding{}
+)
+
+// Register makes a Binding available for the given content type, e.g.
+//
+//	binding.Register("application/vnd.api+json", jsonAPIBinding{})
+//
+// Context.Bind and Context.ShouldBind consult the registered bindings before the
+// built-in ones, so registering a built-in content type overrides it.
+// Registering a nil Binding removes the content type again.
+func Register(contentType string, b Binding) {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if b == nil {
+		delete(registry, contentType)
+		return
+	}
+	registry[contentType] = b
+}
+
+// Lookup returns the Binding registered for the content type, if any.
+func Lookup(contentType string) (Binding, bool) {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	b, ok := registry[contentType]
+	return b, ok
+}
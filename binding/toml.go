@@ -0,0 +1,34 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build toml
+// +build toml
+
+package binding
+
+import (
+	"net/http"
+	"third/toml"
+)
+
+// TOML binding
+type tomlBinding struct{}
+
+// TOML decodes the body as TOML, for the application/toml content type. It is only available
+// when building with the toml tag, which needs third/toml.
+var TOML = tomlBinding{}
+
+func init() {
+	Register("application/toml", TOML)
+}
+
+func (_ tomlBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := setDefaults(obj); err != nil {
+		return err
+	}
+	if _, err := toml.DecodeReader(req.Body, obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
@@ -0,0 +1,174 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type (
+	// StructValidator validates bound objects. Assign an implementation to Validator to
+	// replace the built-in one (e.g. with an adapter around a third party validator).
+	StructValidator interface {
+		ValidateStruct(obj interface{}) error
+	}
+
+	// ValidationFunc checks a single struct field for a `binding:"tag"` or `binding:"tag=param"` rule.
+	// field is the field value and parent the struct that holds it, which allows cross-field rules.
+	ValidationFunc func(field reflect.Value, parent reflect.Value, param string) bool
+
+	// DefaultValidator implements the `binding` struct tag: comma separated rules, "required"
+	// and "eqfield" being built in, others being added with RegisterValidation. Unknown rules,
+	// e.g. the ones of another validator, are ignored.
+	DefaultValidator struct {
+		mu          sync.RWMutex
+		validations map[string]ValidationFunc
+	}
+)
+
+var defaultValidator = NewDefaultValidator()
+
+// Validator is used by every binding to validate the bound object.
+var Validator StructValidator = defaultValidator
+
+// NewDefaultValidator returns a validator with the built-in rules registered.
+func NewDefaultValidator() *DefaultValidator {
+	v := &DefaultValidator{validations: make(map[string]ValidationFunc)}
+	v.RegisterValidation("eqfield", isEqField)
+	return v
+}
+
+// RegisterValidation adds a rule to the built-in validator. A rule registered as "phone"
+// can then be used in struct tags such as `binding:"required,phone"`.
+func RegisterValidation(tag string, fn ValidationFunc) {
+	defaultValidator.RegisterValidation(tag, fn)
+}
+
+// RegisterValidation adds or replaces the rule named tag.
+func (v *DefaultValidator) RegisterValidation(tag string, fn ValidationFunc) {
+	if len(tag) == 0 || tag == "required" {
+		panic("binding: invalid validation tag " + tag)
+	}
+	v.mu.Lock()
+	v.validations[tag] = fn
+	v.mu.Unlock()
+}
+
+// ValidateStruct implements StructValidator.
+func (v *DefaultValidator) ValidateStruct(obj interface{}) error {
+	var errs ValidationErrors
+	v.validate(reflect.ValueOf(obj), "", "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate validates obj with the current Validator.
+func Validate(obj interface{}) error {
+	if Validator == nil {
+		return nil
+	}
+	return Validator.ValidateStruct(obj)
+}
+
+// validate collects into errs every rule violated by val. parent is the name of the struct
+// field holding val and namespace its dotted path from the root object.
+func (v *DefaultValidator) validate(val reflect.Value, parent, namespace string, errs *ValidationErrors) {
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		typ := val.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+
+			// Allow ignored and unexported fields in the struct
+			if len(field.PkgPath) > 0 || field.Tag.Get("form") == "-" {
+				continue
+			}
+
+			fieldValue := val.Field(i)
+			fieldNamespace := field.Name
+			if len(namespace) > 0 {
+				fieldNamespace = namespace + "." + field.Name
+			}
+			zero := isZero(fieldValue)
+			for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+				if fieldErr := v.checkRule(strings.TrimSpace(rule), field, fieldValue, val, zero); fieldErr != nil {
+					fieldErr.Namespace = fieldNamespace
+					fieldErr.Struct = parent
+					*errs = append(*errs, fieldErr)
+					break
+				}
+			}
+
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				if !zero {
+					v.validate(fieldValue, field.Name, fieldNamespace, errs)
+				}
+			case reflect.Slice:
+				if field.Type.Elem().Kind() == reflect.Struct {
+					v.validate(fieldValue, field.Name, fieldNamespace, errs)
+				}
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < val.Len(); i++ {
+			v.validate(val.Index(i), parent, fmt.Sprintf("%s[%d]", namespace, i), errs)
+		}
+	}
+}
+
+func (v *DefaultValidator) checkRule(rule string, field reflect.StructField, fieldValue, parentValue reflect.Value, zero bool) *FieldError {
+	if len(rule) == 0 || rule == "-" {
+		return nil
+	}
+	name, param := rule, ""
+	if index := strings.IndexByte(rule, '='); index >= 0 {
+		name, param = rule[:index], rule[index+1:]
+	}
+
+	if name == "required" {
+		if !zero {
+			return nil
+		}
+	} else {
+		v.mu.RLock()
+		fn, ok := v.validations[name]
+		v.mu.RUnlock()
+		if !ok || fn(fieldValue, parentValue, param) {
+			return nil
+		}
+	}
+	return &FieldError{
+		Field: field.Name,
+		Tag:   name,
+		Param: param,
+		Value: fieldValue.Interface(),
+	}
+}
+
+func isZero(val reflect.Value) bool {
+	return reflect.DeepEqual(reflect.Zero(val.Type()).Interface(), val.Interface())
+}
+
+// isEqField is the "eqfield=Other" rule: the field must equal its sibling named Other.
+func isEqField(field reflect.Value, parent reflect.Value, param string) bool {
+	other := parent.FieldByName(param)
+	if !other.IsValid() {
+		return false
+	}
+	return reflect.DeepEqual(field.Interface(), other.Interface())
+}
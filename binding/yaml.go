@@ -0,0 +1,40 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build yaml
+// +build yaml
+
+package binding
+
+import (
+	"io"
+	"net/http"
+	"third/yaml"
+)
+
+// YAML binding
+type yamlBinding struct{}
+
+// YAML decodes the body as YAML, for the application/x-yaml and application/yaml content
+// types. It is only available when building with the yaml tag, which needs third/yaml.
+var YAML = yamlBinding{}
+
+func init() {
+	Register("application/x-yaml", YAML)
+	Register("application/yaml", YAML)
+}
+
+func (_ yamlBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := setDefaults(obj); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(body, obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
@@ -0,0 +1,25 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+)
+
+// BodyLimit returns a middleware limiting the request bodies to limit bytes. Requests declaring
+// a larger Content-Length are aborted with 413 Request Entity Too Large before any handler
+// reads them; for the others reading past the limit fails, and c.Bind answers 413.
+// Engine.MaxBodySize sets the same limit, without the early check, for every request.
+func BodyLimit(limit int64) HandlerFunc {
+	return func(c *Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+	}
+}
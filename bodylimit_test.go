@@ -0,0 +1,66 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func performBody(r http.Handler, body string, chunked bool) *httptest.ResponseRecorder {
+	var reader io.Reader = strings.NewReader(body)
+	if chunked {
+		// hide the length so the request is sent without Content-Length
+		reader = io.MultiReader(reader)
+	}
+	req, _ := http.NewRequest("POST", "/", reader)
+	req.Header.Set("Content-Type", MIMEJSON)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestBodyLimit tests that oversized bodies are answered with 413.
+func TestBodyLimit(t *testing.T) {
+	called := false
+	r := New()
+	r.Use(BodyLimit(16))
+	r.POST("/", func(c *Context) {
+		called = true
+		var obj map[string]interface{}
+		if c.Bind(&obj) {
+			c.String(200, "ok")
+		}
+	})
+
+	if w := performBody(r, `{"a":1}`, false); w.Code != 200 {
+		t.Errorf("Small body should be accepted, was: %d", w.Code)
+	}
+
+	called = false
+	if w := performBody(r, `{"a":"0123456789abcdef"}`, false); w.Code != 413 || called {
+		t.Errorf("Declared oversized body should be rejected before the handler, was: %d %v", w.Code, called)
+	}
+	if w := performBody(r, `{"a":"0123456789abcdef"}`, true); w.Code != 413 {
+		t.Errorf("Oversized chunked body should be answered with 413, was: %d", w.Code)
+	}
+}
+
+// TestEngineMaxBodySize tests the engine wide limit.
+func TestEngineMaxBodySize(t *testing.T) {
+	r := New()
+	r.MaxBodySize = 16
+	r.POST("/", func(c *Context) {
+		var obj map[string]interface{}
+		c.Bind(&obj)
+	})
+
+	if w := performBody(r, `{"a":"0123456789abcdef"}`, false); w.Code != 413 {
+		t.Errorf("Oversized body should be answered with 413, was: %d", w.Code)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets the requests through, counting the failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects the requests.
+	CircuitOpen
+	// CircuitHalfOpen lets a few probe requests through to decide whether to close again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type (
+	// CircuitBreakerConfig configures the CircuitBreaker middleware.
+	CircuitBreakerConfig struct {
+		// FailureRatio is the ratio of failed requests opening the circuit, 0.5 by default.
+		FailureRatio float64
+		// MinRequests is the number of requests in a window before the circuit can open, 10 by default.
+		MinRequests int
+		// Window is the period over which the failures are counted, 10 seconds by default.
+		Window time.Duration
+		// OpenTimeout is how long the circuit stays open before probing, 30 seconds by default.
+		OpenTimeout time.Duration
+		// HalfOpenRequests is the number of successful probes closing the circuit, 1 by default.
+		HalfOpenRequests int
+		// IsFailure reports whether a request failed, by default when the status is 5xx,
+		// which includes the 503 of the Timeout middleware.
+		IsFailure func(c *Context) bool
+		// OnStateChange, if set, is called when the circuit of a route changes state.
+		OnStateChange func(route string, from, to CircuitState)
+
+		now func() time.Time
+	}
+
+	circuitBreaker struct {
+		mu          sync.Mutex
+		state       CircuitState
+		requests    int
+		failures    int
+		windowStart time.Time
+		openedAt    time.Time
+		probes      int
+		successes   int
+	}
+)
+
+// CircuitBreaker returns a middleware keeping a circuit breaker per route template. When the
+// failure ratio of a route goes over FailureRatio its circuit opens, and its requests are
+// rejected with 503 Service Unavailable and a Retry-After header for OpenTimeout. Then
+// HalfOpenRequests probes are let through: the circuit closes if they all succeed, and
+// opens again on the first failure.
+func CircuitBreaker(config CircuitBreakerConfig) HandlerFunc {
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = 0.5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(c *Context) bool { return c.Writer.Status() >= 500 }
+	}
+	if config.now == nil {
+		config.now = time.Now
+	}
+	var mu sync.Mutex
+	breakers := make(map[string]*circuitBreaker)
+
+	return func(c *Context) {
+		route := c.FullPath()
+		mu.Lock()
+		b, ok := breakers[route]
+		if !ok {
+			b = &circuitBreaker{windowStart: config.now()}
+			breakers[route] = b
+		}
+		mu.Unlock()
+
+		state, retryAfter := b.allow(&config, route)
+		if retryAfter > 0 {
+			c.Writer.Header().Set("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+			c.AbortWithStatus(503)
+			return
+		}
+		failed := true
+		defer func() {
+			b.record(&config, route, state, failed)
+		}()
+		c.Next()
+		failed = config.IsFailure(c)
+	}
+}
+
+// allow returns the state the request is admitted in, or how long it must wait.
+func (b *circuitBreaker) allow(config *CircuitBreakerConfig, route string) (CircuitState, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := config.now()
+	switch b.state {
+	case CircuitOpen:
+		if wait := b.openedAt.Add(config.OpenTimeout).Sub(now); wait > 0 {
+			return b.state, wait
+		}
+		b.setState(config, route, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probes >= config.HalfOpenRequests {
+			return b.state, time.Second
+		}
+		b.probes++
+	default:
+		if now.Sub(b.windowStart) > config.Window {
+			b.requests, b.failures, b.windowStart = 0, 0, now
+		}
+	}
+	return b.state, 0
+}
+
+// record counts the result of a request admitted in state.
+func (b *circuitBreaker) record(config *CircuitBreakerConfig, route string, state CircuitState, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state != b.state {
+		return
+	}
+	switch b.state {
+	case CircuitClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= config.MinRequests && float64(b.failures) >= config.FailureRatio*float64(b.requests) {
+			b.setState(config, route, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			b.setState(config, route, CircuitOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= config.HalfOpenRequests {
+			b.setState(config, route, CircuitClosed)
+		}
+	}
+}
+
+func (b *circuitBreaker) setState(config *CircuitBreakerConfig, route string, state CircuitState) {
+	from := b.state
+	b.state = state
+	b.requests, b.failures, b.probes, b.successes = 0, 0, 0, 0
+	b.windowStart = config.now()
+	if state == CircuitOpen {
+		b.openedAt = b.windowStart
+	}
+	if config.OnStateChange != nil {
+		config.OnStateChange(route, from, state)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker tests that a failing route opens its circuit, then closes after a probe.
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	var changes []string
+	failing := true
+	r := New()
+	r.Use(CircuitBreaker(CircuitBreakerConfig{
+		MinRequests: 4,
+		OpenTimeout: time.Minute,
+		OnStateChange: func(route string, from, to CircuitState) {
+			changes = append(changes, route+" "+from.String()+" -> "+to.String())
+		},
+		now: func() time.Time { return now },
+	}))
+	r.GET("/upstream", func(c *Context) {
+		if failing {
+			c.AbortWithStatus(502)
+		}
+	})
+	r.GET("/other", func(c *Context) {})
+
+	for i := 0; i < 4; i++ {
+		PerformRequest(r, "GET", "/upstream")
+	}
+	w := PerformRequest(r, "GET", "/upstream")
+	if w.Code != 503 || w.HeaderMap.Get("Retry-After") != "60" {
+		t.Errorf("Open circuit should reject the requests, was: %d %q", w.Code, w.HeaderMap.Get("Retry-After"))
+	}
+	if w := PerformRequest(r, "GET", "/other"); w.Code != 200 {
+		t.Errorf("Circuits should be per route, was: %d", w.Code)
+	}
+
+	now = now.Add(time.Minute)
+	if w := PerformRequest(r, "GET", "/upstream"); w.Code != 502 {
+		t.Errorf("Half-open circuit should let a probe through, was: %d", w.Code)
+	}
+	if w := PerformRequest(r, "GET", "/upstream"); w.Code != 503 {
+		t.Errorf("Failed probe should open the circuit again, was: %d", w.Code)
+	}
+
+	now = now.Add(time.Minute)
+	failing = false
+	PerformRequest(r, "GET", "/upstream")
+	if w := PerformRequest(r, "GET", "/upstream"); w.Code != 200 {
+		t.Errorf("Successful probe should close the circuit, was: %d", w.Code)
+	}
+
+	expected := []string{
+		"/upstream closed -> open",
+		"/upstream open -> half-open",
+		"/upstream half-open -> open",
+		"/upstream open -> half-open",
+		"/upstream half-open -> closed",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("State changes should be %v, was: %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("State changes should be %v, was: %v", expected, changes)
+		}
+	}
+}
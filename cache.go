@@ -0,0 +1,288 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CacheStore keeps the responses of the Cache middleware, encoded as bytes so a shared
+	// store can be plugged in: a Redis store is a GET and a SET with the PX option.
+	CacheStore interface {
+		// Get returns the value of key, and false if it is missing or expired.
+		Get(key string) (value []byte, ok bool, err error)
+		// Set stores value under key for ttl.
+		Set(key string, value []byte, ttl time.Duration) error
+	}
+
+	// CacheConfig configures the Cache middleware.
+	CacheConfig struct {
+		// TTL is how long the responses are cached, one minute by default.
+		TTL time.Duration
+		// Key returns the cache key of the request, the method and the URI with its query by
+		// default. An empty key isn't cached.
+		Key func(c *Context) string
+		// MaxBodySize is the size over which responses aren't cached, 1MB by default.
+		MaxBodySize int
+		// Store defaults to a store in memory of 64MB, see NewMemoryCacheStore.
+		Store CacheStore
+	}
+
+	cachedResponse struct {
+		Status int
+		Header http.Header
+		Body   []byte
+		// Vary lists the request headers selecting the variant, for the entry under the
+		// key of the request; the variants are stored under their own keys.
+		Vary []string
+	}
+
+	memoryCacheStore struct {
+		mu       sync.Mutex
+		maxBytes int
+		bytes    int
+		entries  map[string]*list.Element
+		lru      *list.List
+		now      func() time.Time
+	}
+
+	memoryCacheEntry struct {
+		key     string
+		value   []byte
+		expires time.Time
+	}
+)
+
+// Cache returns a middleware caching the 200 responses to GET and HEAD requests, and serving
+// them from the cache until they expire. Responses with a Vary header are cached per value
+// of the headers listed; responses setting a cookie, with Vary: *, or with a Cache-Control of
+// no-store, no-cache or private aren't cached, neither are streamed responses. Requests with
+// an Authorization header only share the responses marked public (RFC 9111, section 3.5).
+// Only the headers set by the handlers after Cache are stored, and a cache hit leaves the
+// headers already set for the request, e.g. X-Request-ID, untouched. Store errors are recorded in c.Errors and the request is handled as if uncached.
+func Cache(config CacheConfig) HandlerFunc {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	if config.Key == nil {
+		config.Key = func(c *Context) string {
+			return c.Request.Method + " " + c.Request.URL.RequestURI()
+		}
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryCacheStore(64 << 20)
+	}
+	return func(c *Context) {
+		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+			return
+		}
+		key := config.Key(c)
+		if key == "" {
+			return
+		}
+		cached, err := config.load(key, c.Request.Header)
+		if err != nil {
+			c.ErrorTyped(err, ErrorTypeInternal, "cache store")
+		}
+		authorized := c.Request.Header.Get("Authorization") != ""
+		if cached != nil && authorized && !hasCacheDirective(cached.Header, "public") {
+			cached = nil
+		}
+		if cached != nil {
+			header := c.Writer.Header()
+			for k, v := range cached.Header {
+				if _, ok := header[k]; !ok {
+					header[k] = v
+				}
+			}
+			c.Writer.WriteHeader(cached.Status)
+			c.Writer.Write(cached.Body)
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		before := cloneHeader(original.Header())
+		w := newBufferedWriter(original)
+		c.Writer = w
+		defer func() { c.Writer = original }()
+
+		c.Next()
+
+		if w.streaming {
+			return
+		}
+		if authorized && !hasCacheDirective(original.Header(), "public") {
+			w.flush()
+			return
+		}
+		if w.status == 200 && w.written && w.body.Len() <= config.MaxBodySize {
+			header := original.Header()
+			if err := config.save(key, c.Request.Header, header, headerChanges(before, header), w.body.Bytes()); err != nil {
+				c.ErrorTyped(err, ErrorTypeInternal, "cache store")
+			}
+		}
+		w.flush()
+	}
+}
+
+func (config *CacheConfig) load(key string, request http.Header) (*cachedResponse, error) {
+	cached, err := config.get(key)
+	if cached == nil || len(cached.Vary) == 0 {
+		return cached, err
+	}
+	return config.get(key + varyKey(request, cached.Vary))
+}
+
+func (config *CacheConfig) get(key string) (*cachedResponse, error) {
+	data, ok, err := config.Store.Get(key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	cached := new(cachedResponse)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(cached); err != nil {
+		return nil, err
+	}
+	return cached, nil
+}
+
+// save stores the response with the written headers, after checking that header, the whole
+// response header, allows it.
+func (config *CacheConfig) save(key string, request, header, written http.Header, body []byte) error {
+	if _, ok := header["Set-Cookie"]; ok {
+		return nil
+	}
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-store", "no-cache", "private":
+			return nil
+		}
+	}
+	var vary []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "*" {
+				return nil
+			} else if name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	if len(vary) > 0 {
+		if err := config.set(key, &cachedResponse{Vary: vary}); err != nil {
+			return err
+		}
+		key += varyKey(request, vary)
+	}
+	return config.set(key, &cachedResponse{Status: 200, Header: written, Body: body})
+}
+
+func (config *CacheConfig) set(key string, cached *cachedResponse) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cached); err != nil {
+		return err
+	}
+	return config.Store.Set(key, buf.Bytes(), config.TTL)
+}
+
+// hasCacheDirective reports whether the Cache-Control header holds directive.
+func hasCacheDirective(header http.Header, directive string) bool {
+	for _, value := range header["Cache-Control"] {
+		for _, d := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(d), directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func varyKey(request http.Header, vary []string) string {
+	key := ""
+	for _, name := range vary {
+		key += "\x00" + name + "=" + strings.Join(request[name], ",")
+	}
+	return key
+}
+
+// headerChanges returns the headers of after that were added or changed since before.
+func headerChanges(before, after http.Header) http.Header {
+	changes := make(http.Header)
+	for k, v := range after {
+		if old, ok := before[k]; !ok || strings.Join(old, "\x00") != strings.Join(v, "\x00") {
+			changes[k] = append([]string(nil), v...)
+		}
+	}
+	return changes
+}
+
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for k, v := range header {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// NewMemoryCacheStore returns a CacheStore keeping up to maxBytes of values in memory,
+// evicting the least recently used ones first.
+func NewMemoryCacheStore(maxBytes int) CacheStore {
+	return &memoryCacheStore{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !s.now().Before(entry.expires) {
+		s.remove(element)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	if len(value) > s.maxBytes {
+		return nil
+	}
+	entry := &memoryCacheEntry{key: key, value: value, expires: s.now().Add(ttl)}
+	s.entries[key] = s.lru.PushFront(entry)
+	s.bytes += len(value)
+	for s.bytes > s.maxBytes {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *memoryCacheStore) remove(element *list.Element) {
+	entry := s.lru.Remove(element).(*memoryCacheEntry)
+	delete(s.entries, entry.key)
+	s.bytes -= len(entry.value)
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCache tests that responses are served from the cache until they expire.
+func TestCache(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryCacheStore(1 << 20).(*memoryCacheStore)
+	store.now = func() time.Time { return now }
+
+	calls := 0
+	r := New()
+	r.Use(Cache(CacheConfig{TTL: time.Minute, Store: store}))
+	r.GET("/items", func(c *Context) {
+		calls++
+		c.Writer.Header().Set("X-Calls", string(rune('0'+calls)))
+		c.String(200, "items %s", c.Query("page"))
+	})
+	r.GET("/private", func(c *Context) {
+		calls++
+		c.Writer.Header().Set("Cache-Control", "private")
+		c.String(200, "private")
+	})
+
+	PerformRequest(r, "GET", "/items?page=1")
+	w := PerformRequest(r, "GET", "/items?page=1")
+	if calls != 1 || w.Body.String() != "items 1" || w.HeaderMap.Get("X-Calls") != "1" {
+		t.Errorf("Response should be served from the cache, was: %d calls, %q", calls, w.Body.String())
+	}
+	PerformRequest(r, "GET", "/items?page=2")
+	if calls != 2 {
+		t.Errorf("Query should be part of the key, was: %d calls", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	PerformRequest(r, "GET", "/items?page=1")
+	if calls != 3 {
+		t.Errorf("Expired response should not be served, was: %d calls", calls)
+	}
+
+	PerformRequest(r, "GET", "/private")
+	PerformRequest(r, "GET", "/private")
+	if calls != 5 {
+		t.Errorf("Private response should not be cached, was: %d calls", calls)
+	}
+}
+
+// TestCacheAuthorization tests that the responses to authenticated requests are only shared
+// when they are public.
+func TestCacheAuthorization(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Use(Cache(CacheConfig{}))
+	r.GET("/me", func(c *Context) {
+		calls++
+		c.String(200, "user "+c.Request.Header.Get("Authorization"))
+	})
+	r.GET("/public", func(c *Context) {
+		calls++
+		c.Writer.Header().Set("Cache-Control", "public, max-age=60")
+		c.String(200, "public")
+	})
+
+	perform := func(path, auth string) string {
+		req, _ := http.NewRequest("GET", path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+	if perform("/me", "alice") != "user alice" || perform("/me", "bob") != "user bob" {
+		t.Errorf("Authenticated responses should not be shared")
+	}
+	if body := perform("/me", ""); body != "user " || calls != 3 {
+		t.Errorf("Authenticated responses should not be cached, was: %q after %d calls", body, calls)
+	}
+	if perform("/me", "alice") != "user alice" {
+		t.Errorf("Anonymous response should not be served to authenticated requests")
+	}
+
+	calls = 0
+	if perform("/public", "alice") != "public" || perform("/public", "bob") != "public" || calls != 1 {
+		t.Errorf("Public responses should be shared, was: %d calls", calls)
+	}
+}
+
+// TestCacheVary tests that responses are cached per value of their Vary headers.
+func TestCacheVary(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Use(Cache(CacheConfig{}))
+	r.GET("/", func(c *Context) {
+		calls++
+		c.Writer.Header().Set("Vary", "Accept-Language")
+		c.String(200, c.Request.Header.Get("Accept-Language"))
+	})
+
+	perform := func(lang string) string {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+	if perform("en") != "en" || perform("fr") != "fr" || perform("en") != "en" || perform("fr") != "fr" {
+		t.Errorf("Variants should be cached separately")
+	}
+	if calls != 2 {
+		t.Errorf("Each variant should be cached, was: %d calls", calls)
+	}
+}
+
+// TestMemoryCacheStoreEviction tests that the least recently used values are evicted.
+func TestMemoryCacheStoreEviction(t *testing.T) {
+	store := NewMemoryCacheStore(10)
+	store.Set("a", []byte("aaaa"), time.Minute)
+	store.Set("b", []byte("bbbb"), time.Minute)
+	store.Get("a")
+	store.Set("c", []byte("cccc"), time.Minute)
+
+	if _, ok, _ := store.Get("b"); ok {
+		t.Errorf("Least recently used value should be evicted")
+	}
+	if _, ok, _ := store.Get("a"); !ok {
+		t.Errorf("Recently used value should be kept")
+	}
+}
+
+// TestCacheRequestHeaders tests that the headers set for the request before Cache are neither
+// stored nor replaced by the cached ones.
+func TestCacheRequestHeaders(t *testing.T) {
+	r := New()
+	r.Use(RequestID(), Cache(CacheConfig{}))
+	r.GET("/", func(c *Context) {
+		c.Writer.Header().Set("X-Handler", "yes")
+		c.String(200, "ok")
+	})
+
+	first := PerformRequest(r, "GET", "/")
+	second := PerformRequest(r, "GET", "/")
+	id := second.HeaderMap.Get(HeaderXRequestID)
+	if id == "" || id == first.HeaderMap.Get(HeaderXRequestID) {
+		t.Errorf("Cached response should keep the request ID of the request, was: %q", id)
+	}
+	if len(second.HeaderMap.Values(HeaderXRequestID)) != 1 || second.HeaderMap.Get("X-Handler") != "yes" {
+		t.Errorf("Cached response should carry the handler headers, was: %v", second.HeaderMap)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package json is the JSON implementation shared by the render and binding packages.
+// It defaults to encoding/json; assign API to switch every JSON render and the JSON
+// binding to another implementation, e.g. a jsoniter or sonic adapter:
+//
+//	json.API = jsoniterAPI{jsoniter.ConfigCompatibleWithStandardLibrary}
+//
+// API is process wide and should be set once at startup, before serving requests.
+package json
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type (
+	// Core is the set of operations gin needs from a JSON implementation.
+	Core interface {
+		Marshal(v interface{}) ([]byte, error)
+		MarshalIndent(v interface{}, prefix, indent string) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+		NewEncoder(w io.Writer) Encoder
+		NewDecoder(r io.Reader) Decoder
+	}
+
+	// Encoder writes JSON values to an output stream.
+	Encoder interface {
+		Encode(v interface{}) error
+		SetEscapeHTML(on bool)
+		SetIndent(prefix, indent string)
+	}
+
+	// Decoder reads JSON values from an input stream.
+	Decoder interface {
+		Decode(v interface{}) error
+		UseNumber()
+		DisallowUnknownFields()
+	}
+
+	stdJSON struct{}
+)
+
+// API is the JSON implementation in use, encoding/json by default.
+var API Core = stdJSON{}
+
+func (_ stdJSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (_ stdJSON) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return json.MarshalIndent(v, prefix, indent)
+}
+
+func (_ stdJSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (_ stdJSON) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (_ stdJSON) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
@@ -0,0 +1,261 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// CompressConfig configures the Compress middleware.
+type CompressConfig struct {
+	// Level is the compression level, gzip.DefaultCompression (-1) when zero.
+	Level int
+	// MinLength is the size under which responses are sent uncompressed, 1024 bytes by default.
+	MinLength int
+	// MIMETypes lists the compressed content types, "type/*" matching a whole type. By default
+	// text/*, JSON, javascript, XML and SVG are compressed.
+	MIMETypes []string
+	// Deflate also offers the deflate encoding, gzip being preferred on equal q-values.
+	Deflate bool
+}
+
+var defaultCompressMIMETypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/x-yaml",
+	"image/svg+xml",
+}
+
+// Gzip returns a middleware compressing the responses with gzip at the given level,
+// e.g. gzip.BestSpeed, see Compress.
+func Gzip(level int) HandlerFunc {
+	return Compress(CompressConfig{Level: level})
+}
+
+// Compress returns a middleware compressing the responses with the encoding negotiated from
+// Accept-Encoding. The response is held back until MinLength bytes are written, to decide on
+// the size and Content-Type; the writer stays a ResponseWriter so Status(), Size() (uncompressed)
+// and Written() keep working, and Flush sends what was compressed so far.
+func Compress(config CompressConfig) HandlerFunc {
+	if config.Level == 0 {
+		config.Level = gzip.DefaultCompression
+	}
+	if config.MinLength == 0 {
+		config.MinLength = 1024
+	}
+	if len(config.MIMETypes) == 0 {
+		config.MIMETypes = defaultCompressMIMETypes
+	}
+	if _, err := gzip.NewWriterLevel(io.Discard, config.Level); err != nil {
+		panic(err)
+	}
+	gzipPool := &sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, config.Level)
+		return w
+	}}
+	flatePool := &sync.Pool{New: func() interface{} {
+		w, _ := flate.NewWriter(io.Discard, config.Level)
+		return w
+	}}
+
+	return func(c *Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.Request.Header.Get("Accept-Encoding"), config.Deflate)
+		if encoding == "" || c.Request.Header.Get("Upgrade") != "" {
+			return
+		}
+		original := c.Writer
+		w := &compressWriter{
+			ResponseWriter: original,
+			config:         &config,
+			encoding:       encoding,
+			gzipPool:       gzipPool,
+			flatePool:      flatePool,
+		}
+		c.Writer = w
+		defer func() {
+			c.Writer = original
+			if err := recover(); err != nil {
+				// what is held back is dropped so an outer Recovery can still send its 500
+				w.abort()
+				panic(err)
+			}
+			w.close()
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns the preferred supported encoding of an Accept-Encoding header.
+// The "*" wildcard stands for the supported encodings not refused with q=0.
+func negotiateEncoding(acceptEncoding string, deflate bool) string {
+	acceptEncoding = strings.ToLower(acceptEncoding)
+	refused := make(map[string]bool)
+	for _, r := range parseMediaRanges(acceptEncoding) {
+		if r.q <= 0 {
+			refused[r.value] = true
+		}
+	}
+	for _, encoding := range parseAccept(acceptEncoding) {
+		switch {
+		case encoding == "gzip" && !refused["gzip"]:
+			return "gzip"
+		case encoding == "deflate" && deflate && !refused["deflate"]:
+			return "deflate"
+		case encoding == "*" && !refused["gzip"]:
+			return "gzip"
+		case encoding == "*" && deflate && !refused["deflate"]:
+			return "deflate"
+		}
+	}
+	return ""
+}
+
+type compressWriter struct {
+	ResponseWriter
+	config     *CompressConfig
+	encoding   string
+	gzipPool   *sync.Pool
+	flatePool  *sync.Pool
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+	}
+	pending   []byte
+	decided   bool
+	headerNow bool
+	size      int
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	if !w.decided {
+		w.pending = append(w.pending, data...)
+		if len(w.pending) < w.config.MinLength {
+			return len(data), nil
+		}
+		return len(data), w.decide()
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerNow = true
+}
+
+func (w *compressWriter) Written() bool {
+	return w.headerNow || w.pending != nil || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Size() int {
+	if !w.Written() {
+		return NoWritten
+	}
+	return w.size
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.compressor != nil {
+		w.compressor.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide compresses the response from now on if it is eligible, and sends what is pending.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.pending) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.pending))
+	}
+	// partial responses are left alone, their ranges are offsets into the uncompressed body
+	if len(w.pending) >= w.config.MinLength && header.Get("Content-Encoding") == "" &&
+		w.Status() != http.StatusPartialContent && header.Get("Content-Range") == "" &&
+		bodyAllowedForStatus(w.Status()) && w.compressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if w.encoding == "gzip" {
+			gz := w.gzipPool.Get().(*gzip.Writer)
+			gz.Reset(w.ResponseWriter)
+			w.compressor = gz
+		} else {
+			fl := w.flatePool.Get().(*flate.Writer)
+			fl.Reset(w.ResponseWriter)
+			w.compressor = fl
+		}
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	pending := w.pending
+	w.pending = nil
+	if len(pending) == 0 {
+		return nil
+	}
+	if w.compressor != nil {
+		_, err := w.compressor.Write(pending)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(pending)
+	return err
+}
+
+func (w *compressWriter) compressible(contentType string) bool {
+	contentType = filterFlags(contentType)
+	for _, mimeType := range w.config.MIMETypes {
+		if matchMediaRange(mimeType, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// abort drops what is still held back and returns the compressor to its pool, without
+// sending anything more.
+func (w *compressWriter) abort() {
+	w.pending = nil
+	w.headerNow = false
+	if gz, ok := w.compressor.(*gzip.Writer); ok {
+		w.gzipPool.Put(gz)
+	} else if w.compressor != nil {
+		w.flatePool.Put(w.compressor)
+	}
+	w.compressor = nil
+}
+
+// close sends what is still held back and returns the compressor to its pool.
+func (w *compressWriter) close() {
+	if !w.decided && !w.Written() {
+		return
+	}
+	if !w.decided {
+		w.decide()
+	}
+	if w.compressor == nil {
+		return
+	}
+	w.compressor.Close()
+	if gz, ok := w.compressor.(*gzip.Writer); ok {
+		w.gzipPool.Put(gz)
+	} else {
+		w.flatePool.Put(w.compressor)
+	}
+	w.compressor = nil
+}
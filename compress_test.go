@@ -0,0 +1,152 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func performCompressed(r http.Handler, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestCompressGzip tests that large compressible responses are gzipped and the rest sent as is.
+func TestCompressGzip(t *testing.T) {
+	large := strings.Repeat("gin ", 1000)
+	var status, size int
+	var written bool
+	r := New()
+	r.Use(Gzip(gzip.BestSpeed))
+	r.GET("/large", func(c *Context) {
+		c.String(201, large)
+		status, size, written = c.Writer.Status(), c.Writer.Size(), c.Writer.Written()
+	})
+	r.GET("/small", func(c *Context) {
+		c.String(200, "small")
+	})
+	r.GET("/image", func(c *Context) {
+		c.Data(200, "image/png", []byte(large))
+	})
+
+	w := performCompressed(r, "/large", "gzip")
+	if w.Code != 201 || w.HeaderMap.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Response should be gzipped, was: %d %v", w.Code, w.HeaderMap)
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(gz)
+	if string(body) != large {
+		t.Errorf("Decompressed body doesn't match")
+	}
+	if status != 201 || size != len(large) || !written {
+		t.Errorf("Writer should report 201, %d and written, was: %d %d %v", len(large), status, size, written)
+	}
+
+	if w := performCompressed(r, "/small", "gzip"); w.HeaderMap.Get("Content-Encoding") != "" || w.Body.String() != "small" {
+		t.Errorf("Small responses should not be compressed, was: %v %s", w.HeaderMap, w.Body.String())
+	}
+	if w := performCompressed(r, "/image", "gzip"); w.HeaderMap.Get("Content-Encoding") != "" || w.Body.Len() != len(large) {
+		t.Errorf("Images should not be compressed, was: %v", w.HeaderMap)
+	}
+	if w := performCompressed(r, "/large", "br, identity"); w.HeaderMap.Get("Content-Encoding") != "" || w.Body.String() != large {
+		t.Errorf("Response should not be compressed without a supported encoding, was: %v", w.HeaderMap)
+	}
+}
+
+// TestCompressRange tests that partial responses are sent uncompressed.
+func TestCompressRange(t *testing.T) {
+	large := strings.Repeat("gin ", 1000)
+	file, err := os.CreateTemp("", "gin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(large)
+	file.Close()
+
+	r := New()
+	r.Use(Gzip(gzip.BestSpeed))
+	r.GET("/file", func(c *Context) {
+		c.File(file.Name())
+	})
+
+	req, _ := http.NewRequest("GET", "/file", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=0-1999")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 206 || w.HeaderMap.Get("Content-Encoding") != "" || w.Body.String() != large[:2000] {
+		t.Errorf("Partial response should not be compressed, was: %d %v", w.Code, w.HeaderMap)
+	}
+
+	if w := performCompressed(r, "/file", "gzip"); w.Code != 200 || w.HeaderMap.Get("Content-Encoding") != "gzip" {
+		t.Errorf("Full response should be compressed, was: %d %v", w.Code, w.HeaderMap)
+	}
+}
+
+// TestCompressDeflate tests the deflate encoding negotiation.
+func TestCompressDeflate(t *testing.T) {
+	large := strings.Repeat("gin ", 1000)
+	r := New()
+	r.Use(Compress(CompressConfig{Deflate: true}))
+	r.GET("/", func(c *Context) {
+		c.String(200, large)
+	})
+
+	w := performCompressed(r, "/", "gzip;q=0.5, deflate")
+	if w.HeaderMap.Get("Content-Encoding") != "deflate" {
+		t.Fatalf("Response should be deflated, was: %v", w.HeaderMap)
+	}
+	body, _ := io.ReadAll(flate.NewReader(w.Body))
+	if string(body) != large {
+		t.Errorf("Decompressed body doesn't match")
+	}
+}
+
+// TestCompressPanic tests that a panic drops the held back response so Recovery can answer 500.
+func TestCompressPanic(t *testing.T) {
+	r := New()
+	r.Use(RecoveryWithConfig(RecoveryConfig{Output: io.Discard}), Compress(CompressConfig{}))
+	r.GET("/", func(c *Context) {
+		c.String(200, "partial")
+		panic("oops")
+	})
+
+	w := performCompressed(r, "/", "gzip")
+	if w.Code != 500 || strings.Contains(w.Body.String(), "partial") {
+		t.Errorf("Response should be a 500 without the partial body, was: %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestCompressRefused tests that encodings refused with q=0 aren't chosen for the wildcard.
+func TestCompressRefused(t *testing.T) {
+	for acceptEncoding, expected := range map[string]string{
+		"gzip;q=0, *":                "deflate",
+		"gzip;q=0, deflate;q=0, *":   "",
+		"*":                          "gzip",
+		"identity, gzip;q=0.5":       "gzip",
+		"deflate;q=0, gzip;q=0.1, *": "gzip",
+	} {
+		if encoding := negotiateEncoding(acceptEncoding, true); encoding != expected {
+			t.Errorf("Encoding for %q should be %q, was: %q", acceptEncoding, expected, encoding)
+		}
+	}
+	if encoding := negotiateEncoding("gzip;q=0, *", false); encoding != "" {
+		t.Errorf("Refused gzip should not be chosen, was: %q", encoding)
+	}
+}
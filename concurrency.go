@@ -0,0 +1,73 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyLimitConfig configures the ConcurrencyLimit middleware.
+type ConcurrencyLimitConfig struct {
+	// MaxInFlight is the number of requests handled at once.
+	MaxInFlight int
+	// MaxQueue is the number of requests waiting for a slot, none when zero.
+	MaxQueue int
+	// QueueTimeout is how long a request waits for a slot, one second by default.
+	QueueTimeout time.Duration
+	// RetryAfter is sent in the Retry-After header of the rejected requests, one second by default.
+	RetryAfter time.Duration
+}
+
+// ConcurrencyLimit returns a middleware capping the number of requests in flight through it:
+// used on the engine it limits the whole server, used on a group only the routes of the group.
+// Requests over the limit wait in a bounded queue, and are rejected with 503 Service
+// Unavailable and a Retry-After header when the queue is full or their wait times out.
+func ConcurrencyLimit(config ConcurrencyLimitConfig) HandlerFunc {
+	if config.MaxInFlight <= 0 {
+		panic("concurrency limit must be positive")
+	}
+	if config.QueueTimeout <= 0 {
+		config.QueueTimeout = time.Second
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+	retryAfter := strconv.FormatInt(int64((config.RetryAfter+time.Second-1)/time.Second), 10)
+	slots := make(chan struct{}, config.MaxInFlight)
+	var queued int64
+
+	return func(c *Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if atomic.AddInt64(&queued, 1) > int64(config.MaxQueue) {
+				atomic.AddInt64(&queued, -1)
+				c.Writer.Header().Set("Retry-After", retryAfter)
+				c.AbortWithStatus(503)
+				return
+			}
+			timer := time.NewTimer(config.QueueTimeout)
+			select {
+			case slots <- struct{}{}:
+				timer.Stop()
+				atomic.AddInt64(&queued, -1)
+			case <-timer.C:
+				atomic.AddInt64(&queued, -1)
+				c.Writer.Header().Set("Retry-After", retryAfter)
+				c.AbortWithStatus(503)
+				return
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				atomic.AddInt64(&queued, -1)
+				c.Abort()
+				return
+			}
+		}
+		defer func() { <-slots }()
+		c.Next()
+	}
+}
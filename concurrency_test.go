@@ -0,0 +1,67 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestConcurrencyLimit tests that requests over the limit are queued, then rejected.
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	r := New()
+	r.Use(ConcurrencyLimit(ConcurrencyLimitConfig{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: time.Minute}))
+	r.GET("/", func(c *Context) {
+		started <- struct{}{}
+		<-release
+	})
+
+	codes := make(chan int, 2)
+	perform := func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes <- w.Code
+	}
+	go perform()
+	<-started
+	go perform()
+	time.Sleep(20 * time.Millisecond)
+
+	w := PerformRequest(r, "GET", "/")
+	if w.Code != 503 || w.HeaderMap.Get("Retry-After") != "1" {
+		t.Errorf("Request over the queue should be rejected, was: %d %q", w.Code, w.HeaderMap.Get("Retry-After"))
+	}
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	if a, b := <-codes, <-codes; a != 200 || b != 200 {
+		t.Errorf("Queued request should be handled, was: %d %d", a, b)
+	}
+}
+
+// TestConcurrencyLimitTimeout tests that queued requests give up after QueueTimeout.
+func TestConcurrencyLimitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	r := New()
+	r.Use(ConcurrencyLimit(ConcurrencyLimitConfig{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond}))
+	r.GET("/", func(c *Context) {
+		close(started)
+		<-release
+	})
+
+	go PerformRequest(r, "GET", "/")
+	<-started
+	if w := PerformRequest(r, "GET", "/"); w.Code != 503 {
+		t.Errorf("Queued request should time out, was: %d", w.Code)
+	}
+	close(release)
+}
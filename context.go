@@ -7,57 +7,21 @@ package gin
 import (
 	"bytes"
 	"errors"
-	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"third/gin/binding"
 	"third/gin/render"
 	"third/httprouter"
+	"time"
 )
 
-const (
-	ErrorTypeInternal = 1 << iota
-	ErrorTypeExternal = 1 << iota
-	ErrorTypeAll      = 0xffffffff
-)
-
-// Used internally to collect errors that occurred during an http request.
-type errorMsg struct {
-	Err  string      `json:"error"`
-	Type uint32      `json:"-"`
-	Meta interface{} `json:"meta"`
-}
-
-type errorMsgs []errorMsg
-
-func (a errorMsgs) ByType(typ uint32) errorMsgs {
-	if len(a) == 0 {
-		return a
-	}
-	result := make(errorMsgs, 0, len(a))
-	for _, msg := range a {
-		if msg.Type&typ > 0 {
-			result = append(result, msg)
-		}
-	}
-	return result
-}
-
-func (a errorMsgs) String() string {
-	if len(a) == 0 {
-		return ""
-	}
-	var buffer bytes.Buffer
-	for i, msg := range a {
-		text := fmt.Sprintf("Error #%02d: %s \n     Meta: %v\n", (i + 1), msg.Err, msg.Meta)
-		buffer.WriteString(text)
-	}
-	return buffer.String()
-}
-
 // Context is the most important part of gin. It allows us to pass variables between middleware,
 // manage the flow, validate the JSON of a request and render a JSON response for example.
 type Context struct {
@@ -65,10 +29,12 @@ type Context struct {
 	Request   *http.Request
 	Writer    ResponseWriter
 	Keys      map[string]interface{}
+	keysMutex sync.RWMutex
 	Errors    errorMsgs
 	Params    httprouter.Params
 	Engine    *Engine
 	handlers  []HandlerFunc
+	fullPath  string
 	index     int8
 	accepted  []string
 }
@@ -77,11 +43,13 @@ type Context struct {
 /********** CONTEXT CREATION ********/
 /************************************/
 
-func (engine *Engine) createContext(w http.ResponseWriter, req *http.Request, params httprouter.Params, handlers []HandlerFunc) *Context {
+func (engine *Engine) createContext(w http.ResponseWriter, req *http.Request, params httprouter.Params, fullPath string, handlers []HandlerFunc) *Context {
 	c := engine.pool.Get().(*Context)
 	c.writermem.reset(w)
+	c.Writer = &c.writermem
 	c.Request = req
 	c.Params = params
+	c.fullPath = fullPath
 	c.handlers = handlers
 	c.Keys = nil
 	c.index = -1
@@ -91,14 +59,34 @@ func (engine *Engine) createContext(w http.ResponseWriter, req *http.Request, pa
 }
 
 func (engine *Engine) reuseContext(c *Context) {
-	engine.pool.Put(c)
+	if !engine.noContextReuse {
+		engine.pool.Put(c)
+	}
 }
 
+// Copy returns a copy of the current context that can be safely used outside the request's scope.
+// This has to be used when the context has to be passed to a goroutine.
 func (c *Context) Copy() *Context {
-	var cp Context = *c
-	cp.index = AbortIndex
-	cp.handlers = nil
-	return &cp
+	cp := &Context{
+		writermem: c.writermem,
+		Request:   c.Request,
+		Writer:    c.Writer,
+		Errors:    c.Errors,
+		Params:    c.Params,
+		Engine:    c.Engine,
+		fullPath:  c.fullPath,
+		index:     AbortIndex,
+		accepted:  c.accepted,
+	}
+	c.keysMutex.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]interface{}, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	c.keysMutex.RUnlock()
+	return cp
 }
 
 /************************************/
@@ -128,6 +116,13 @@ func (c *Context) AbortWithStatus(code int) {
 	c.Abort()
 }
 
+// AbortWithStatusJSON calls Abort and then renders obj as JSON with the specified status code.
+// It replaces the error prone c.JSON + c.Abort pair in middlewares.
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
 /************************************/
 /********* ERROR MANAGEMENT *********/
 /************************************/
@@ -143,28 +138,51 @@ func (c *Context) Fail(code int, err error) {
 	c.AbortWithStatus(code)
 }
 
-func (c *Context) ErrorTyped(err error, typ uint32, meta interface{}) {
-	c.Errors = append(c.Errors, errorMsg{
-		Err:  err.Error(),
-		Type: typ,
-		Meta: meta,
-	})
+// AbortWithError calls AbortWithStatus and then attaches err to the context.
+// The returned *Error can be used to set its type and meta data, e.g.
+// c.AbortWithError(500, err).SetType(gin.ErrorTypePrivate)
+func (c *Context) AbortWithError(code int, err error) *Error {
+	c.AbortWithStatus(code)
+	return c.Error(err)
+}
+
+// ErrorTyped attaches an error of the given type (see ErrorTypePrivate, ErrorTypePublic and
+// ErrorTypeBind) to the current context.
+func (c *Context) ErrorTyped(err error, typ uint32, meta interface{}) *Error {
+	if err == nil {
+		panic("err is nil")
+	}
+	parsedError, ok := err.(*Error)
+	if !ok {
+		parsedError = &Error{
+			Err:  err,
+			Type: typ,
+			Meta: meta,
+		}
+	}
+	c.Errors = append(c.Errors, parsedError)
+	return parsedError
 }
 
 // Attaches an error to the current context. The error is pushed to a list of errors.
 // It's a good idea to call Error for each error that occurred during the resolution of a request.
 // A middleware can be used to collect all the errors and push them to a database together, print a log, or append it in the HTTP response.
-func (c *Context) Error(err error, meta interface{}) {
-	c.ErrorTyped(err, ErrorTypeExternal, meta)
+// The error is public by default, the returned *Error can be used to change its type and meta data:
+//
+//	c.Error(err).SetType(gin.ErrorTypePrivate).SetMeta(userID)
+func (c *Context) Error(err error, meta ...interface{}) *Error {
+	var m interface{}
+	if len(meta) > 0 {
+		m = meta[0]
+	}
+	return c.ErrorTyped(err, ErrorTypePublic, m)
 }
 
 func (c *Context) LastError() error {
-	nuErrors := len(c.Errors)
-	if nuErrors > 0 {
-		return errors.New(c.Errors[nuErrors-1].Err)
-	} else {
-		return nil
+	if last := c.Errors.Last(); last != nil {
+		return last
 	}
+	return nil
 }
 
 /************************************/
@@ -172,21 +190,23 @@ func (c *Context) LastError() error {
 /************************************/
 
 // Sets a new pair key/value just for the specified context.
-// It also lazy initializes the hashmap.
+// It also lazy initializes the hashmap. It is safe to call from concurrent goroutines.
 func (c *Context) Set(key string, item interface{}) {
+	c.keysMutex.Lock()
 	if c.Keys == nil {
 		c.Keys = make(map[string]interface{})
 	}
 	c.Keys[key] = item
+	c.keysMutex.Unlock()
 }
 
 // Get returns the value for the given key or an error if the key does not exist.
 func (c *Context) Get(key string) (interface{}, error) {
-	if c.Keys != nil {
-		value, ok := c.Keys[key]
-		if ok {
-			return value, nil
-		}
+	c.keysMutex.RLock()
+	value, ok := c.Keys[key]
+	c.keysMutex.RUnlock()
+	if ok {
+		return value, nil
 	}
 	return nil, errors.New("Key does not exist.")
 }
@@ -195,11 +215,68 @@ func (c *Context) Get(key string) (interface{}, error) {
 func (c *Context) MustGet(key string) interface{} {
 	value, err := c.Get(key)
 	if err != nil || value == nil {
-		log.Panicf("Key %s doesn't exist", value)
+		log.Panicf("Key %s doesn't exist", key)
 	}
 	return value
 }
 
+// GetString returns the value associated with the key as a string.
+// An empty string is returned if the key does not exist or holds another type.
+func (c *Context) GetString(key string) (s string) {
+	if val, err := c.Get(key); err == nil && val != nil {
+		s, _ = val.(string)
+	}
+	return
+}
+
+// GetInt64 returns the value associated with the key as an int64.
+// Values stored as int or int32 are converted as well.
+func (c *Context) GetInt64(key string) (i64 int64) {
+	if val, err := c.Get(key); err == nil && val != nil {
+		switch v := val.(type) {
+		case int64:
+			i64 = v
+		case int:
+			i64 = int64(v)
+		case int32:
+			i64 = int64(v)
+		}
+	}
+	return
+}
+
+// GetBool returns the value associated with the key as a boolean.
+func (c *Context) GetBool(key string) (b bool) {
+	if val, err := c.Get(key); err == nil && val != nil {
+		b, _ = val.(bool)
+	}
+	return
+}
+
+// GetTime returns the value associated with the key as time.
+func (c *Context) GetTime(key string) (t time.Time) {
+	if val, err := c.Get(key); err == nil && val != nil {
+		t, _ = val.(time.Time)
+	}
+	return
+}
+
+// GetRawData returns the raw request body. When Engine.CacheRequestBody is enabled the
+// body is restored afterwards, so it can be read again by c.Bind or another GetRawData.
+func (c *Context) GetRawData() ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Engine.CacheRequestBody {
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	return data, nil
+}
+
 func (c *Context) Query(key string) (va string) {
 	va, _ = c.query(key)
 	return
@@ -214,6 +291,12 @@ func (c *Context) Param(key string) string {
 	return c.Params.ByName(key)
 }
 
+// FullPath returns the route template matched by the current request, e.g. "/users/:id".
+// It returns an empty string for unmatched requests (NoRoute and NoMethod handlers).
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) DefaultPostForm(key, defaultValue string) string {
 	if va, ok := c.postForm(key); ok {
 		return va
@@ -239,7 +322,7 @@ func (c *Context) query(key string) (string, bool) {
 
 func (c *Context) postForm(key string) (string, bool) {
 	req := c.Request
-	req.ParseMultipartForm(32 << 20)
+	req.ParseMultipartForm(c.Engine.MaxMultipartMemory)
 	if values := req.PostForm[key]; len(values) > 0 {
 		return values[0], true
 	}
@@ -252,6 +335,37 @@ func (c *Context) postForm(key string) (string, bool) {
 	return "", false
 }
 
+// FormFile returns the first file for the provided form key.
+// Up to Engine.MaxMultipartMemory bytes of the form are kept in memory, the rest is
+// stored in temporary files.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(c.Engine.MaxMultipartMemory); err != nil {
+			return nil, err
+		}
+	}
+	_, fh, err := c.Request.FormFile(name)
+	return fh, err
+}
+
+// SaveUploadedFile copies the uploaded file to dst, creating or truncating it.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
 func ipInMasks(ip net.IP, masks []interface{}) bool {
 	for _, proxy := range masks {
 		var mask *net.IPNet
@@ -280,15 +394,18 @@ func ipInMasks(ip net.IP, masks []interface{}) bool {
 // middleware if you've got servers in front of this server. The list with (known) proxies and
 // local ips are being filtered out of the forwarded for list, giving the last not local ip being
 // the real client ip.
+// Without proxies the engine settings are used, see Engine.SetTrustedProxies, so the rewritten
+// address is the one returned by ClientIP.
 func ForwardedFor(proxies ...interface{}) HandlerFunc {
 	if len(proxies) == 0 {
-		// default to local ips
-		var reservedLocalIps = []string{"10.0.0.0/8", "127.0.0.1/32", "172.16.0.0/12", "192.168.0.0/16"}
-
-		proxies = make([]interface{}, len(reservedLocalIps))
-
-		for i, v := range reservedLocalIps {
-			proxies[i] = v
+		return func(c *Context) {
+			ip := net.ParseIP(c.ClientIP())
+			host, _, _ := net.SplitHostPort(c.Request.RemoteAddr)
+			if ip == nil || ip.Equal(net.ParseIP(host)) {
+				return
+			}
+			c.Request.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+			c.Request.Header.Set("X-Forwarded-For", "")
 		}
 	}
 
@@ -328,22 +445,47 @@ func ForwardedFor(proxies ...interface{}) HandlerFunc {
 	}
 }
 
-// ClientIP returns client real IP
+// ClientIP returns client real IP.
+// The headers in Engine.RemoteIPHeaders are only consulted when the direct peer is one of the
+// trusted proxies (see Engine.SetTrustedProxies), otherwise the peer address is returned.
+// X-Forwarded-For is walked from right to left and the first address that is not a trusted
+// proxy is returned, so a client can't spoof its IP by sending the header itself.
 func (c *Context) ClientIP() string {
-	clientIP := c.Request.Header.Get("http_x_forwarded_for")
-	if clientIP != "" {
-		return clientIP
+	remoteAddr := strings.TrimSpace(c.Request.RemoteAddr)
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
 	}
-	clientIP = c.Request.Header.Get("X-Forwarded-For")
-	if clientIP != "" {
-		return clientIP
+	remoteIP := net.ParseIP(host)
+	if remoteIP == nil || !c.Engine.isTrustedProxy(remoteIP) {
+		return host
 	}
-	clientIP = c.Request.Header.Get("X-Real-IP")
-	if clientIP != "" {
-		return clientIP
+	for _, headerName := range c.Engine.RemoteIPHeaders {
+		if ip, ok := c.Engine.forwardedClientIP(c.Request.Header.Get(headerName)); ok {
+			return ip
+		}
 	}
-	h, _, _ := net.SplitHostPort(c.Request.RemoteAddr)
-	return h
+	return host
+}
+
+// forwardedClientIP returns the right-most address of a forwarded header that was not
+// added by a trusted proxy. Malformed headers are ignored.
+func (engine *Engine) forwardedClientIP(header string) (string, bool) {
+	if header == "" {
+		return "", false
+	}
+	items := strings.Split(header, ",")
+	for i := len(items) - 1; i >= 0; i-- {
+		ipStr := strings.TrimSpace(items[i])
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			return "", false
+		}
+		if i == 0 || !engine.isTrustedProxy(ip) {
+			return ipStr, true
+		}
+	}
+	return "", false
 }
 
 // GetReqID return codoon request_id from header
@@ -365,6 +507,72 @@ func (c *Context) SetReqID(reqID int64) int64 {
 	return reqID
 }
 
+/************************************/
+/************ GO CONTEXT ************/
+/************************************/
+
+// Deadline returns the time when work done on behalf of this request should be canceled.
+// It is backed by the context of the underlying http.Request, so *Context satisfies
+// context.Context and can be passed directly to database and RPC calls.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Request == nil {
+		return
+	}
+	return c.Request.Context().Deadline()
+}
+
+// Done returns a channel that is closed when the request is canceled, for example
+// when the client closes the connection or the server is shutting down.
+func (c *Context) Done() <-chan struct{} {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Done()
+}
+
+// Err returns a non-nil error once Done is closed, explaining why the request was canceled.
+func (c *Context) Err() error {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Err()
+}
+
+// Value returns the value associated with key. String keys are looked up in c.Keys first,
+// every other key falls through to the context of the underlying http.Request.
+func (c *Context) Value(key interface{}) interface{} {
+	if keyAsString, ok := key.(string); ok {
+		if value, err := c.Get(keyAsString); err == nil {
+			return value
+		}
+	}
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Value(key)
+}
+
+// Locale returns the locale detected by the I18n middleware, empty without it.
+func (c *Context) Locale() string {
+	if locale, err := c.Get(LocaleKey); err == nil {
+		if s, ok := locale.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// T translates key in the request locale with the Translator of the I18n middleware.
+// Without the middleware the key is returned, formatted with args.
+func (c *Context) T(key string, args ...interface{}) string {
+	if value, err := c.Get(TranslatorKey); err == nil {
+		if t, ok := value.(*Translator); ok {
+			return t.Translate(c.Locale(), key, args...)
+		}
+	}
+	return formatMessage(key, args)
+}
+
 /************************************/
 /********* PARSING REQUEST **********/
 /************************************/
@@ -373,41 +581,163 @@ func (c *Context) SetReqID(reqID int64) int64 {
 // Depending the "Content-Type" header different bindings are used:
 // "application/json" --> JSON binding
 // "application/xml"  --> XML binding
+// "application/x-yaml" --> YAML binding, with the yaml build tag
+// "application/x-protobuf" --> Protocol Buffers binding, with the protobuf build tag
+// "application/x-msgpack" --> MessagePack binding, with the msgpack build tag
+// "application/toml" --> TOML binding, with the toml build tag
+// types registered with binding.Register --> the registered binding
 // else --> returns an error
 // if Parses the request's body as JSON if Content-Type == "application/json"  using JSON or XML  as a JSON input. It decodes the json payload into the struct specified as a pointer.Like ParseBody() but this method also writes a 400 error if the json is not valid.
 func (c *Context) Bind(obj interface{}) bool {
-	var b binding.Binding
-	ctype := filterFlags(c.Request.Header.Get("Content-Type"))
-	switch {
-	case c.Request.Method == "GET" || c.Request.Method == "DELETE" || ctype == MIMEPOSTForm || ctype == MIMEPOSTForm2B:
-		b = binding.Form
-	case ctype == MIMEMultipartPOSTForm:
-		b = binding.MultipartForm
-	case ctype == MIMEJSON:
-		b = binding.JSON
-	case ctype == MIMEXML || ctype == MIMEXML2:
-		b = binding.XML
-	default:
-		c.Fail(400, errors.New("unknown content-type: "+ctype))
+	b, err := c.defaultBinding()
+	if err != nil {
+		c.ErrorTyped(err, ErrorTypeExternal|ErrorTypeBind, "Operation aborted")
+		c.AbortWithStatus(400)
 		return false
 	}
 	return c.BindWith(obj, b)
 }
 
+// BindWith binds with the given binding engine like Bind. A body over the size limit,
+// see BodyLimit and Engine.MaxBodySize, is answered with 413 instead of 400.
 func (c *Context) BindWith(obj interface{}, b binding.Binding) bool {
-	if err := b.Bind(c.Request, obj); err != nil {
-		c.Fail(400, err)
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		c.ErrorTyped(err, ErrorTypeExternal|ErrorTypeBind, "Operation aborted")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		} else {
+			c.AbortWithStatus(400)
+		}
+		return false
+	}
+	return true
+}
+
+// ShouldBind is like Bind but it only returns the error, leaving the response untouched
+// so the caller can render its own error.
+func (c *Context) ShouldBind(obj interface{}) error {
+	b, err := c.defaultBinding()
+	if err != nil {
+		return err
+	}
+	return c.ShouldBindWith(obj, b)
+}
+
+// ShouldBindJSON binds the body as JSON, using binding.StrictJSON when
+// Engine.StrictJSONBinding is set and binding.JSON otherwise.
+// Use c.ShouldBindWith(obj, binding.StrictJSON) to enable strict mode for a single call.
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	return c.ShouldBindWith(obj, c.jsonBinding())
+}
+
+func (c *Context) jsonBinding() binding.Binding {
+	if c.Engine.StrictJSONBinding {
+		return binding.StrictJSON
+	}
+	return binding.JSON
+}
+
+// ShouldBindXML is a shortcut for c.ShouldBindWith(obj, binding.XML).
+func (c *Context) ShouldBindXML(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.XML)
+}
+
+// ShouldBindQuery is a shortcut for c.ShouldBindWith(obj, binding.Query).
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.Query)
+}
+
+// BindQuery binds the URL query string only, whatever the request method and Content-Type are.
+// Like Bind it writes a 400 and aborts if binding or validation fails.
+func (c *Context) BindQuery(obj interface{}) bool {
+	return c.BindWith(obj, binding.Query)
+}
+
+// BindUri binds the path parameters of the matched route into obj using `uri:"name"` tags.
+// Like Bind it writes a 400 and aborts if binding or validation fails.
+func (c *Context) BindUri(obj interface{}) bool {
+	if err := c.ShouldBindUri(obj); err != nil {
+		c.ErrorTyped(err, ErrorTypeExternal|ErrorTypeBind, "Operation aborted")
+		c.AbortWithStatus(400)
 		return false
 	}
 	return true
 }
 
+// ShouldBindUri is like BindUri but it only returns the error.
+func (c *Context) ShouldBindUri(obj interface{}) error {
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	return binding.Uri.BindUri(params, obj)
+}
+
+// BindHeader binds the request headers into obj using `header:"X-Name"` tags.
+// Like Bind it writes a 400 and aborts if binding or validation fails.
+func (c *Context) BindHeader(obj interface{}) bool {
+	return c.BindWith(obj, binding.Header)
+}
+
+// ShouldBindHeader is a shortcut for c.ShouldBindWith(obj, binding.Header).
+func (c *Context) ShouldBindHeader(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.Header)
+}
+
+// ShouldBindWith binds the request into obj using the specified binding engine.
+// Unlike BindWith it neither writes a 400 nor records the error on the context.
+func (c *Context) ShouldBindWith(obj interface{}, b binding.Binding) error {
+	return b.Bind(c.Request, obj)
+}
+
+// defaultBinding selects the binding engine from the request method and Content-Type.
+func (c *Context) defaultBinding() (binding.Binding, error) {
+	ctype := filterFlags(c.Request.Header.Get("Content-Type"))
+	switch {
+	case c.Request.Method == "GET" || c.Request.Method == "DELETE":
+		return binding.Form, nil
+	}
+	if b, ok := binding.Lookup(ctype); ok {
+		return b, nil
+	}
+	switch {
+	case ctype == MIMEPOSTForm || ctype == MIMEPOSTForm2B:
+		return binding.Form, nil
+	case ctype == MIMEMultipartPOSTForm:
+		return binding.MultipartFormMemory(c.Engine.MaxMultipartMemory), nil
+	case ctype == MIMEJSON:
+		return c.jsonBinding(), nil
+	case ctype == MIMEXML || ctype == MIMEXML2:
+		return binding.XML, nil
+	default:
+		return nil, errors.New("unknown content-type: " + ctype)
+	}
+}
+
 /************************************/
 /******** RESPONSE RENDERING ********/
 /************************************/
 
-func (c *Context) Render(code int, render render.Render, obj ...interface{}) {
-	if err := render.Render(c.Writer, code, obj...); err != nil {
+// Status sets the response status code without writing a body.
+// The header is sent once the handlers chain returns, so Written() stays false until then
+// and a later render can still override the code.
+func (c *Context) Status(code int) {
+	c.Writer.WriteHeader(code)
+}
+
+// Render writes the response with r, the entry point of every built-in render, so custom renders
+// (Excel, PDF, NDJSON...) get the same status handling: obj is passed to r as is, the status
+// and Written() are tracked by c.Writer, and a render error is recorded in c.Errors as an
+// internal error before aborting with a 500.
+// Responses to 1xx, 204 and 304 must not have a body, for those codes only the header is sent.
+func (c *Context) Render(code int, r render.Render, obj ...interface{}) {
+	if !bodyAllowedForStatus(code) {
+		c.Writer.WriteHeader(code)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	if err := r.Render(c.Writer, code, obj...); err != nil {
 		c.ErrorTyped(err, ErrorTypeInternal, obj)
 		c.AbortWithStatus(500)
 	}
@@ -415,14 +745,67 @@ func (c *Context) Render(code int, render render.Render, obj ...interface{}) {
 
 // Serializes the given struct as JSON into the response body in a fast and efficient way.
 // It also sets the Content-Type as "application/json".
+// When Engine.IndentJSONInDebug is set and gin runs in debug mode the output is pretty printed.
 func (c *Context) JSON(code int, obj interface{}) {
+	if c.Engine.IndentJSONInDebug && IsDebugging() {
+		c.IndentedJSON(code, obj)
+		return
+	}
 	c.Render(code, render.JSON, obj)
 }
 
+// IndentedJSON serializes the given struct as pretty-printed JSON into the response body.
+// It is meant for development, as it uses more CPU and bandwidth than JSON.
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.Render(code, render.IndentedJSON, obj)
+}
+
+// SecureJSON serializes the given struct as JSON into the response body.
+// Top level arrays are prefixed with Engine.SecureJSONPrefix to prevent JSON hijacking.
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	c.Render(code, render.SecureJSON, obj, c.Engine.SecureJSONPrefix)
+}
+
+// JSONP serializes the given struct as JSON wrapped in the callback named by the "callback"
+// query parameter, with the Content-Type "application/javascript".
+// It falls back to plain JSON when no callback is given, and aborts with 400 when the callback
+// isn't a javascript identifier or dotted path.
+func (c *Context) JSONP(code int, obj interface{}) {
+	callback := c.DefaultQuery("callback", "")
+	if callback == "" {
+		c.JSON(code, obj)
+		return
+	}
+	if !render.ValidJSONPCallback(callback) {
+		c.Fail(400, render.ErrJSONPCallback)
+		return
+	}
+	c.Render(code, render.JSONP, obj, callback)
+}
+
+// PureJSON serializes the given struct as JSON into the response body.
+// Unlike JSON it does not replace special html characters with their unicode entities.
+func (c *Context) PureJSON(code int, obj interface{}) {
+	c.Render(code, render.PureJSON, obj)
+}
+
+// AsciiJSON serializes the given struct as JSON into the response body with every
+// non-ASCII character escaped as \uXXXX.
+func (c *Context) AsciiJSON(code int, obj interface{}) {
+	c.Render(code, render.AsciiJSON, obj)
+}
+
 // Serializes the given struct as XML into the response body in a fast and efficient way.
 // It also sets the Content-Type as "application/xml".
+// Engine.XMLOptions customizes the root element, map attributes and the XML declaration.
 func (c *Context) XML(code int, obj interface{}) {
-	c.Render(code, render.XML, obj)
+	c.Render(code, render.XML, obj, c.Engine.XMLOptions)
+}
+
+// CSV streams the given [][]string or slice of structs as "text/csv".
+// For structs the header line is generated from the `csv` tags, see render.CSV.
+func (c *Context) CSV(code int, rows interface{}) {
+	c.Render(code, render.CSV, rows)
 }
 
 // Renders the HTTP template specified by its file name.
@@ -432,6 +815,18 @@ func (c *Context) HTML(code int, name string, obj interface{}) {
 	c.Render(code, c.Engine.HTMLRender, name, obj)
 }
 
+// HTMLSet renders the template called name of the template set registered with
+// Engine.AddHTMLSet. An unknown set is an internal error answered with a 500.
+func (c *Context) HTMLSet(code int, set, name string, obj interface{}) {
+	r, ok := c.Engine.htmlSets[set]
+	if !ok {
+		c.ErrorTyped(errors.New("unknown template set: "+set), ErrorTypeInternal, name)
+		c.AbortWithStatus(500)
+		return
+	}
+	c.Render(code, r, name, obj)
+}
+
 // Writes the given string into the response body and sets the Content-Type to "text/plain".
 func (c *Context) String(code int, format string, values ...interface{}) {
 	c.Render(code, render.Plain, format, values)
@@ -443,41 +838,158 @@ func (c *Context) HTMLString(code int, format string, values ...interface{}) {
 }
 
 // Returns a HTTP redirect to the specific location.
+// The Location header and status are written through c.Writer, so Written() and Status()
+// reflect the redirect. It panics if code is not a redirect status code (300-308, or 201).
 func (c *Context) Redirect(code int, location string) {
-	if code >= 300 && code <= 308 {
-		c.Render(code, render.Redirect, location)
-	} else {
-		panic(fmt.Sprintf("Cannot send a redirect with status code %d", code))
+	c.Render(code, render.Redirect, location, c.Request)
+}
+
+// SetLastModified sets the Last-Modified validator of the response, checked by IsFresh.
+func (c *Context) SetLastModified(modtime time.Time) {
+	if !modtime.IsZero() {
+		c.Writer.Header().Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
+	}
+}
+
+// SetETag sets the ETag validator of the response, checked by IsFresh. The tag is quoted
+// if it isn't already, e.g. `W/"v1"` is kept while v1 becomes `"v1"`.
+func (c *Context) SetETag(etag string) {
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	c.Writer.Header().Set("ETag", etag)
+}
+
+// IsFresh reports whether the client already has the response to this GET or HEAD request,
+// according to the validators set with SetETag and SetLastModified: If-None-Match is compared
+// with the ETag or, without it, If-Modified-Since with Last-Modified.
+// Handlers can check it to skip building a response answered with 304 anyway.
+func (c *Context) IsFresh() bool {
+	if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+		return false
+	}
+	header := c.Writer.Header()
+	if ifNoneMatch := c.Request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		etag := header.Get("ETag")
+		return etag != "" && etagMatch(ifNoneMatch, etag)
+	}
+	ifModifiedSince, err := http.ParseTime(c.Request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
 	}
+	return !lastModified.After(ifModifiedSince)
 }
 
 // Writes some data into the body stream and updates the HTTP code.
+// A 200 response whose validators show the client is up to date, see IsFresh,
+// is answered with 304 Not Modified instead, and Range requests get the 206 Partial Content
+// of the requested bytes.
 func (c *Context) Data(code int, contentType string, data []byte) {
+	if code == 200 && c.IsFresh() {
+		writeNotModified(c.Writer)
+		return
+	}
 	if len(contentType) > 0 {
 		c.Writer.Header().Set("Content-Type", contentType)
 	}
+	if code == 200 && c.Request.Header.Get("Range") != "" {
+		c.Content("", time.Time{}, bytes.NewReader(data))
+		return
+	}
 	c.Writer.WriteHeader(code)
 	c.Writer.Write(data)
 }
 
-// Writes the specified file into the body stream
+// DataFromReader copies contentLength bytes from reader into the body stream without
+// buffering the whole payload. extraHeaders are added unless already set on the response.
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	c.Render(code, render.Reader, render.ReaderData{
+		ContentType:   contentType,
+		ContentLength: contentLength,
+		Reader:        reader,
+		Headers:       extraHeaders,
+	})
+}
+
+// Content serves the content of a seekable reader, e.g. an *os.File or a *bytes.Reader,
+// with the support of http.ServeContent: Range requests answered with 206 Partial Content
+// and the right Content-Range, If-Range, and conditional requests checked against modtime
+// and the ETag set with SetETag. The Content-Type defaults to the one of the name extension,
+// or is sniffed from the content.
+func (c *Context) Content(name string, modtime time.Time, content io.ReadSeeker) {
+	http.ServeContent(c.Writer, c.Request, name, modtime, content)
+}
+
+// Writes the specified file into the body stream.
+// Range and If-Range requests are honored, so large files can be resumed.
+// Conditional requests are answered with 304 from the file modification time and the ETag
+// set with SetETag, if any.
 func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
 
+// Writes the specified file into the body stream as a download named filename.
+// Non-ASCII filenames are encoded as described in RFC 6266.
+func (c *Context) FileAttachment(filepath, filename string) {
+	c.Writer.Header().Set("Content-Disposition", contentDisposition("attachment", filename))
+	http.ServeFile(c.Writer, c.Request, filepath)
+}
+
+// SSEvent writes a Server-Sent Event named name into the body stream and flushes it.
+// The event-stream headers are set on the first call.
+func (c *Context) SSEvent(name string, message interface{}) {
+	c.Render(-1, render.SSE, render.SSEvent{
+		Event: name,
+		Data:  message,
+	})
+}
+
+// Stream sends a chunked response by calling step until it returns false or the client
+// goes away. The writer is flushed after every step. It returns true when the client
+// disconnected in the middle of the stream.
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	w := c.Writer
+	clientGone := c.Done()
+	for {
+		select {
+		case <-clientGone:
+			return true
+		default:
+			keepOpen := step(w)
+			w.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
 /************************************/
 /******** CONTENT NEGOTIATION *******/
 /************************************/
 
+// msgpackRender is render.MsgPack with the msgpack build tag, nil otherwise.
+var msgpackRender render.Render
+
 type Negotiate struct {
-	Offered  []string
-	HTMLPath string
-	HTMLData interface{}
-	JSONData interface{}
-	XMLData  interface{}
-	Data     interface{}
+	Offered     []string
+	HTMLPath    string
+	HTMLData    interface{}
+	JSONData    interface{}
+	XMLData     interface{}
+	TextData    interface{}
+	MsgPackData interface{}
+	Data        interface{}
 }
 
+// Negotiate renders the data matching the best format accepted by the client among
+// config.Offered (MIMEJSON, MIMEXML, MIMEHTML, MIMEPlain and, with the msgpack build tag,
+// MIMEMSGPACK are supported).
+// It fails with 406 Not Acceptable when none of the offered formats is accepted.
 func (c *Context) Negotiate(code int, config Negotiate) {
 	switch c.NegotiateFormat(config.Offered...) {
 	case MIMEJSON:
@@ -495,11 +1007,25 @@ func (c *Context) Negotiate(code int, config Negotiate) {
 		data := chooseData(config.XMLData, config.Data)
 		c.XML(code, data)
 
+	case MIMEPlain:
+		data := chooseData(config.TextData, config.Data)
+		c.String(code, "%v", data)
+
+	case MIMEMSGPACK, MIMEMSGPACK2:
+		if msgpackRender == nil {
+			panic("negotiate config is wrong. msgpack needs the msgpack build tag")
+		}
+		data := chooseData(config.MsgPackData, config.Data)
+		c.Render(code, msgpackRender, data)
+
 	default:
 		c.Fail(http.StatusNotAcceptable, errors.New("the accepted formats are not offered by the server"))
 	}
 }
 
+// NegotiateFormat returns the offered format that best matches the Accept header,
+// honoring q-values and wildcards. It returns the first offer when the header is empty
+// and "" when nothing matches, including when every range is refused with q=0.
 func (c *Context) NegotiateFormat(offered ...string) string {
 	if len(offered) == 0 {
 		panic("you must provide at least one offer")
@@ -508,12 +1034,15 @@ func (c *Context) NegotiateFormat(offered ...string) string {
 		c.accepted = parseAccept(c.Request.Header.Get("Accept"))
 	}
 	if len(c.accepted) == 0 {
+		if strings.TrimSpace(c.Request.Header.Get("Accept")) != "" {
+			return ""
+		}
 		return offered[0]
 
 	} else {
 		for _, accepted := range c.accepted {
 			for _, offert := range offered {
-				if accepted == offert {
+				if matchMediaRange(accepted, offert) {
 					return offert
 				}
 			}
@@ -6,11 +6,24 @@ package gin
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
+	"third/gin/binding"
+	"third/gin/codec/json"
+	"third/gin/render"
+	"time"
 )
 
 // TestContextParamsGet tests that a parameter can be parsed from the URL.
@@ -214,11 +227,11 @@ func TestHandlerFunc(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 404 {
-		t.Errorf("Response code should be Not found, was: %s", w.Code)
+		t.Errorf("Response code should be Not found, was: %d", w.Code)
 	}
 
 	if stepsPassed != 2 {
-		t.Errorf("Falied to switch context in handler function: %s", stepsPassed)
+		t.Errorf("Falied to switch context in handler function: %d", stepsPassed)
 	}
 }
 
@@ -329,7 +342,7 @@ func TestBindingJSON(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 200 {
-		t.Errorf("Response code should be Ok, was: %s", w.Code)
+		t.Errorf("Response code should be Ok, was: %d", w.Code)
 	}
 
 	if w.Body.String() != "{\"parsed\":\"bar\"}\n" {
@@ -362,7 +375,7 @@ func TestBindingJSONEncoding(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 200 {
-		t.Errorf("Response code should be Ok, was: %s", w.Code)
+		t.Errorf("Response code should be Ok, was: %d", w.Code)
 	}
 
 	if w.Body.String() != "{\"parsed\":\"嘉\"}\n" {
@@ -395,7 +408,7 @@ func TestBindingJSONNoContentType(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 400 {
-		t.Errorf("Response code should be Bad request, was: %s", w.Code)
+		t.Errorf("Response code should be Bad request, was: %d", w.Code)
 	}
 
 	if w.Body.String() == "{\"parsed\":\"bar\"}\n" {
@@ -430,7 +443,7 @@ func TestBindingJSONMalformed(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	if w.Code != 400 {
-		t.Errorf("Response code should be Bad request, was: %s", w.Code)
+		t.Errorf("Response code should be Bad request, was: %d", w.Code)
 	}
 	if w.Body.String() == "{\"parsed\":\"bar\"}\n" {
 		t.Errorf("Response should not be {\"parsed\":\"bar\"}, was: %s", w.Body.String())
@@ -517,3 +530,1531 @@ func TestClientIPWithXForwardedForWithProxy(t *testing.T) {
 		t.Errorf("ClientIP should not be %s, but 1.2.3.4:0", clientIP)
 	}
 }
+
+// TestClientIPConsistent tests that the middlewares agree on the client IP given by the
+// trusted proxy settings of the engine.
+func TestClientIPConsistent(t *testing.T) {
+	r := New()
+	if err := r.SetTrustedProxies([]string{"10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	r.RemoteIPHeaders = []string{"X-Real-IP"}
+	var out bytes.Buffer
+	var remoteAddr string
+	r.Use(LoggerJSON(&out, "client_ip"), RateLimit(RateLimitConfig{Rate: 0.001}), ForwardedFor())
+	r.GET("/", func(c *Context) {
+		remoteAddr = c.Request.RemoteAddr
+	})
+
+	perform := func(remoteAddr, realIP string) int {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Real-IP", realIP)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := perform("10.0.0.1:1234", "8.8.8.8"); code != 200 {
+		t.Errorf("First request should pass, was: %d", code)
+	}
+	if remoteAddr != "8.8.8.8:0" {
+		t.Errorf("ForwardedFor should use the trusted proxies of the engine, was: %s", remoteAddr)
+	}
+	if !strings.Contains(out.String(), `"client_ip":"8.8.8.8"`) {
+		t.Errorf("Logger should log the forwarded client IP, was: %s", out.String())
+	}
+	if code := perform("10.0.0.1:5678", "8.8.8.8"); code != 429 {
+		t.Errorf("RateLimit should limit the forwarded client IP, was: %d", code)
+	}
+	if code := perform("10.0.0.2:1234", "8.8.8.8"); code != 200 {
+		t.Errorf("Untrusted peers should be limited on their own address, was: %d", code)
+	}
+
+	out.Reset()
+	if code := perform("10.0.0.1:1234", "2001:db8::1"); code != 200 {
+		t.Errorf("IPv6 client should pass, was: %d", code)
+	}
+	if remoteAddr != "[2001:db8::1]:0" || !strings.Contains(out.String(), `"client_ip":"2001:db8::1"`) {
+		t.Errorf("IPv6 client IP should be kept, was: %s %s", remoteAddr, out.String())
+	}
+}
+
+// TestContextImplementsGoContext tests that the gin Context follows the
+// cancellation and values of the underlying request context.
+func TestContextImplementsGoContext(t *testing.T) {
+	var ctx context.Context
+	var done <-chan struct{}
+	var value interface{}
+
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.Set("foo", "bar")
+		ctx = c
+		done = c.Done()
+		value = c.Value("foo")
+	})
+
+	parent, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req = req.WithContext(parent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if ctx == nil {
+		t.Fatal("Handler was not invoked")
+	}
+	if value != "bar" {
+		t.Errorf("Value should be bar, was %v", value)
+	}
+	cancel()
+	select {
+	case <-done:
+	default:
+		t.Errorf("Done channel should be closed after the request context is canceled")
+	}
+}
+
+// TestContextTypedGetters tests the typed accessors on top of Get.
+func TestContextTypedGetters(t *testing.T) {
+	now := time.Now()
+
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.Set("string", "bar")
+		c.Set("int", 42)
+		c.Set("bool", true)
+		c.Set("time", now)
+
+		if v := c.GetString("string"); v != "bar" {
+			t.Errorf("GetString should be bar, was %s", v)
+		}
+		if v := c.GetInt64("int"); v != 42 {
+			t.Errorf("GetInt64 should be 42, was %d", v)
+		}
+		if v := c.GetBool("bool"); !v {
+			t.Errorf("GetBool should be true")
+		}
+		if v := c.GetTime("time"); !v.Equal(now) {
+			t.Errorf("GetTime should be %v, was %v", now, v)
+		}
+		if v := c.GetString("int"); v != "" {
+			t.Errorf("GetString on a non string value should be empty, was %s", v)
+		}
+		if v := c.GetInt64("missing"); v != 0 {
+			t.Errorf("GetInt64 on a missing key should be 0, was %d", v)
+		}
+	})
+
+	PerformRequest(r, "GET", "/test")
+}
+
+// TestContextRedirect tests that Redirect writes the Location header through
+// the gin writer and panics on non redirect codes.
+func TestContextRedirect(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.Redirect(http.StatusMovedPermanently, "/other")
+		if !c.Writer.Written() {
+			t.Errorf("Writer should be marked as written after a redirect")
+		}
+	})
+	r.GET("/bad", func(c *Context) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Redirect with status 200 should panic")
+			}
+		}()
+		c.Redirect(200, "/other")
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("Response code should be 301, was: %d", w.Code)
+	}
+	if w.HeaderMap.Get("Location") != "/other" {
+		t.Errorf("Location should be /other, was %s", w.HeaderMap.Get("Location"))
+	}
+
+	PerformRequest(r, "GET", "/bad")
+}
+
+// TestContextFileAttachment tests that a file is served as a download and
+// that range requests are honored.
+func TestContextFileAttachment(t *testing.T) {
+	r := New()
+	r.GET("/ascii", func(c *Context) {
+		c.FileAttachment("./gin.go", "export.go")
+	})
+	r.GET("/utf8", func(c *Context) {
+		c.FileAttachment("./gin.go", "报表.go")
+	})
+	r.GET("/separators", func(c *Context) {
+		c.FileAttachment("./gin.go", `résumé; "v2".go`)
+	})
+
+	req, _ := http.NewRequest("GET", "/ascii", nil)
+	req.Header.Set("Range", "bytes=0-9")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPartialContent {
+		t.Errorf("Response code should be 206, was: %d", w.Code)
+	}
+	if w.Body.Len() != 10 {
+		t.Errorf("Body should have 10 bytes, was %d", w.Body.Len())
+	}
+	if h := w.HeaderMap.Get("Content-Disposition"); h != `attachment; filename="export.go"` {
+		t.Errorf("Unexpected Content-Disposition %s", h)
+	}
+
+	w = PerformRequest(r, "GET", "/utf8")
+	if h := w.HeaderMap.Get("Content-Disposition"); h != `attachment; filename="__.go"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.go` {
+		t.Errorf("Unexpected Content-Disposition %s", h)
+	}
+
+	w = PerformRequest(r, "GET", "/separators")
+	if h := w.HeaderMap.Get("Content-Disposition"); h != `attachment; filename="r_sum_; \"v2\".go"; filename*=UTF-8''r%C3%A9sum%C3%A9%3B%20%22v2%22.go` {
+		t.Errorf("Unexpected Content-Disposition %s", h)
+	}
+}
+
+// TestContextStream tests that Stream calls the step function until it
+// returns false and flushes every chunk.
+func TestContextStream(t *testing.T) {
+	var disconnected bool
+
+	r := New()
+	r.GET("/test", func(c *Context) {
+		count := 0
+		disconnected = c.Stream(func(w io.Writer) bool {
+			count++
+			fmt.Fprintf(w, "chunk%d\n", count)
+			return count < 3
+		})
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+
+	if disconnected {
+		t.Errorf("Stream should not report a client disconnection")
+	}
+	if !w.Flushed {
+		t.Errorf("Stream should flush the writer")
+	}
+	if w.Body.String() != "chunk1\nchunk2\nchunk3\n" {
+		t.Errorf("Unexpected body %q", w.Body.String())
+	}
+}
+
+// TestContextStreamClientGone tests that Stream stops once the request
+// context is canceled.
+func TestContextStreamClientGone(t *testing.T) {
+	var disconnected bool
+
+	r := New()
+	r.GET("/test", func(c *Context) {
+		disconnected = c.Stream(func(w io.Writer) bool {
+			return true
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if !disconnected {
+		t.Errorf("Stream should report a client disconnection")
+	}
+}
+
+// TestContextSSEvent tests the Server-Sent Events framing and headers.
+func TestContextSSEvent(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.SSEvent("message", "hello\nworld")
+		c.SSEvent("data", H{"foo": "bar"})
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+
+	expected := "event:message\ndata:hello\ndata:world\n\nevent:data\ndata:{\"foo\":\"bar\"}\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("Response should be %q, was %q", expected, w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Content-Type should be text/event-stream, was %s", w.HeaderMap.Get("Content-Type"))
+	}
+	if !w.Flushed {
+		t.Errorf("Events should be flushed")
+	}
+}
+
+// TestContextDataFromReader tests that a reader is copied into the response
+// with the given length, type and extra headers.
+func TestContextDataFromReader(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		body := "#!PNG some raw data"
+		c.DataFromReader(200, int64(len(body)), "image/png", strings.NewReader(body), map[string]string{
+			"Content-Disposition": `attachment; filename="gopher.png"`,
+		})
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+
+	if w.Body.String() != "#!PNG some raw data" {
+		t.Errorf("Unexpected body %q", w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Type") != "image/png" {
+		t.Errorf("Content-Type should be image/png, was %s", w.HeaderMap.Get("Content-Type"))
+	}
+	if w.HeaderMap.Get("Content-Length") != "19" {
+		t.Errorf("Content-Length should be 19, was %s", w.HeaderMap.Get("Content-Length"))
+	}
+	if w.HeaderMap.Get("Content-Disposition") != `attachment; filename="gopher.png"` {
+		t.Errorf("Extra headers should be set")
+	}
+}
+
+// TestContextDataFromReaderLonger tests that only ContentLength bytes of a longer reader are sent.
+func TestContextDataFromReaderLonger(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.DataFromReader(200, 5, "text/plain", strings.NewReader("hello world"), nil)
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+	if w.Body.String() != "hello" || w.HeaderMap.Get("Content-Length") != "5" {
+		t.Errorf("Body should be cut at the Content-Length, was: %q", w.Body.String())
+	}
+}
+
+// TestContextGetRawDataCached tests that the body can be bound after being
+// read by GetRawData when the engine caches request bodies.
+func TestContextGetRawDataCached(t *testing.T) {
+	r := New()
+	r.CacheRequestBody = true
+	r.Use(func(c *Context) {
+		data, err := c.GetRawData()
+		if err != nil || string(data) != "{\"foo\":\"bar\"}" {
+			t.Errorf("Unexpected raw data %q (%v)", data, err)
+		}
+	})
+	r.POST("/test", func(c *Context) {
+		var body struct {
+			Foo string `json:"foo"`
+		}
+		if c.Bind(&body) {
+			c.String(200, body.Foo)
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/test", bytes.NewBufferString("{\"foo\":\"bar\"}"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 || w.Body.String() != "bar" {
+		t.Errorf("Body should be bound after GetRawData, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestContextFormFile tests that an uploaded file can be read and saved.
+func TestContextFormFile(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("file", "test.txt")
+	fw.Write([]byte("uploaded content"))
+	mw.Close()
+
+	dst := path.Join(os.TempDir(), "gin_test_upload.txt")
+	defer os.Remove(dst)
+
+	r := New()
+	r.POST("/upload", func(c *Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			t.Fatalf("FormFile failed: %v", err)
+		}
+		if fh.Filename != "test.txt" {
+			t.Errorf("Filename should be test.txt, was %s", fh.Filename)
+		}
+		if err := c.SaveUploadedFile(fh, dst); err != nil {
+			t.Errorf("SaveUploadedFile failed: %v", err)
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/upload", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != "uploaded content" {
+		t.Errorf("Saved file should contain the upload, was %q (%v)", data, err)
+	}
+}
+
+// TestClientIPTrustedProxies tests that forwarded headers are only honored
+// when they are set by a trusted proxy.
+func TestClientIPTrustedProxies(t *testing.T) {
+	r := New()
+
+	var clientIP string
+	r.GET("/", func(c *Context) {
+		clientIP = c.ClientIP()
+	})
+
+	perform := func(remoteAddr string, headers map[string]string) string {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return clientIP
+	}
+
+	forwarded := map[string]string{"X-Forwarded-For": "1.2.3.4, 10.0.0.2"}
+	if ip := perform("8.8.8.8:1234", forwarded); ip != "8.8.8.8" {
+		t.Errorf("Untrusted peers should not be able to spoof the client IP, was %s", ip)
+	}
+	if ip := perform("10.0.0.1:1234", forwarded); ip != "1.2.3.4" {
+		t.Errorf("ClientIP should be 1.2.3.4, was %s", ip)
+	}
+	if ip := perform("10.0.0.1:1234", map[string]string{"X-Real-IP": "5.6.7.8"}); ip != "5.6.7.8" {
+		t.Errorf("ClientIP should be 5.6.7.8, was %s", ip)
+	}
+
+	r.RemoteIPHeaders = []string{"CF-Connecting-IP"}
+	if err := r.SetTrustedProxies([]string{"8.8.8.8"}); err != nil {
+		t.Fatal(err)
+	}
+	if ip := perform("8.8.8.8:1234", map[string]string{"CF-Connecting-IP": "9.9.9.9", "X-Real-IP": "5.6.7.8"}); ip != "9.9.9.9" {
+		t.Errorf("ClientIP should be 9.9.9.9, was %s", ip)
+	}
+	if ip := perform("10.0.0.1:1234", forwarded); ip != "10.0.0.1" {
+		t.Errorf("ClientIP should ignore headers from a no longer trusted proxy, was %s", ip)
+	}
+	if err := r.SetTrustedProxies([]string{"not an ip"}); err == nil {
+		t.Errorf("SetTrustedProxies should fail on invalid input")
+	}
+}
+
+// TestContextFullPath tests that the matched route template is exposed to
+// handlers and middlewares.
+func TestContextFullPath(t *testing.T) {
+	var fullPath, middlewarePath string
+
+	r := New()
+	r.Use(func(c *Context) {
+		middlewarePath = c.FullPath()
+	})
+	v1 := r.Group("/v1")
+	v1.GET("/users/:id", func(c *Context) {
+		fullPath = c.FullPath()
+	})
+
+	PerformRequest(r, "GET", "/v1/users/42")
+	if fullPath != "/v1/users/:id" || middlewarePath != "/v1/users/:id" {
+		t.Errorf("FullPath should be /v1/users/:id, was %s and %s", fullPath, middlewarePath)
+	}
+
+	PerformRequest(r, "GET", "/missing")
+	if middlewarePath != "" {
+		t.Errorf("FullPath should be empty for unmatched routes, was %s", middlewarePath)
+	}
+}
+
+// TestAbortWithStatusJSON tests that the chain is aborted and the JSON body
+// is rendered with the given status.
+func TestAbortWithStatusJSON(t *testing.T) {
+	stepsPassed := 0
+	r := New()
+	r.Use(func(c *Context) {
+		stepsPassed++
+		c.AbortWithStatusJSON(http.StatusUnauthorized, H{"error": "unauthorized"})
+	})
+	r.GET("/test", func(c *Context) {
+		stepsPassed++
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+
+	if stepsPassed != 1 {
+		t.Errorf("Handlers after AbortWithStatusJSON should not run, steps: %d", stepsPassed)
+	}
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Response code should be 401, was: %d", w.Code)
+	}
+	if w.Body.String() != "{\"error\":\"unauthorized\"}\n" {
+		t.Errorf("Unexpected body %s", w.Body.String())
+	}
+}
+
+// TestAbortWithError tests that the status is set and the error attached.
+func TestAbortWithError(t *testing.T) {
+	var errs errorMsgs
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.AbortWithError(http.StatusBadGateway, errors.New("upstream")).SetType(ErrorTypePrivate)
+		errs = c.Errors
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Response code should be 502, was: %d", w.Code)
+	}
+	if len(errs.ByType(ErrorTypePrivate)) != 1 {
+		t.Errorf("A private error should be attached, got %v", errs)
+	}
+}
+
+// TestContextNegotiate tests that the best offered format is rendered
+// according to q-values and wildcards of the Accept header.
+func TestContextNegotiate(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.Negotiate(200, Negotiate{
+			Offered:  []string{MIMEJSON, MIMEXML, MIMEPlain},
+			Data:     H{"foo": "bar"},
+			TextData: "foo=bar",
+		})
+	})
+
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+	}{
+		{"", 200, "application/json; charset=utf-8"},
+		{"application/xml", 200, "application/xml; charset=utf-8"},
+		{"application/json;q=0.5, application/xml;q=0.9", 200, "application/xml; charset=utf-8"},
+		{"text/*", 200, "text/plain; charset=utf-8"},
+		{"image/png, */*;q=0.1", 200, "application/json; charset=utf-8"},
+		{"image/png", http.StatusNotAcceptable, ""},
+		{"application/json;q=0", http.StatusNotAcceptable, ""},
+		{"*/*, text/*, application/xml", 200, "application/xml; charset=utf-8"},
+		{"*/*;q=0.5, text/*;q=0.5", 200, "text/plain; charset=utf-8"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("Accept %q: response code should be %d, was %d", test.accept, test.code, w.Code)
+		}
+		if test.contentType != "" && w.HeaderMap.Get("Content-Type") != test.contentType {
+			t.Errorf("Accept %q: Content-Type should be %s, was %s", test.accept, test.contentType, w.HeaderMap.Get("Content-Type"))
+		}
+	}
+}
+
+// TestContextStatus tests that Status records the code and writes it once
+// the chain finishes.
+func TestContextStatus(t *testing.T) {
+	r := New()
+	r.DELETE("/test", func(c *Context) {
+		c.Status(http.StatusNoContent)
+		if c.Writer.Written() {
+			t.Errorf("Status should not write the header immediately")
+		}
+		if c.Writer.Status() != http.StatusNoContent {
+			t.Errorf("Status should be recorded, was %d", c.Writer.Status())
+		}
+	})
+
+	w := PerformRequest(r, "DELETE", "/test")
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Response code should be 204, was: %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Body should be empty, was %q", w.Body.String())
+	}
+}
+
+// TestContextSecureJSON tests that arrays are prefixed and objects are not.
+func TestContextSecureJSON(t *testing.T) {
+	r := New()
+	r.GET("/array", func(c *Context) {
+		c.SecureJSON(200, []string{"foo", "bar"})
+	})
+	r.GET("/object", func(c *Context) {
+		c.SecureJSON(200, H{"foo": "bar"})
+	})
+
+	w := PerformRequest(r, "GET", "/array")
+	if w.Body.String() != `while(1);["foo","bar"]` {
+		t.Errorf("Array should be prefixed, was %s", w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type should be application/json, was %s", w.HeaderMap.Get("Content-Type"))
+	}
+
+	w = PerformRequest(r, "GET", "/object")
+	if w.Body.String() != `{"foo":"bar"}` {
+		t.Errorf("Object should not be prefixed, was %s", w.Body.String())
+	}
+
+	r.SecureJSONPrefix = ")]}',\n"
+	w = PerformRequest(r, "GET", "/array")
+	if w.Body.String() != ")]}',\n[\"foo\",\"bar\"]" {
+		t.Errorf("Custom prefix should be used, was %s", w.Body.String())
+	}
+}
+
+// TestContextJSONP tests that the JSON body is wrapped in the callback.
+func TestContextJSONP(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.JSONP(200, H{"foo": "bar"})
+	})
+
+	w := PerformRequest(r, "GET", "/test?callback=x")
+	if w.Body.String() != `x({"foo":"bar"});` {
+		t.Errorf("Response should be wrapped in the callback, was %s", w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Type") != "application/javascript; charset=utf-8" {
+		t.Errorf("Content-Type should be application/javascript, was %s", w.HeaderMap.Get("Content-Type"))
+	}
+
+	w = PerformRequest(r, "GET", "/test?callback=app.on_data$1")
+	if w.Body.String() != `app.on_data$1({"foo":"bar"});` {
+		t.Errorf("Dotted callbacks should be allowed, was %s", w.Body.String())
+	}
+
+	for _, callback := range []string{"alert('x')", "alert(1);foo", "a.", "1a", "a b"} {
+		w = PerformRequest(r, "GET", "/test?callback="+url.QueryEscape(callback))
+		if w.Code != 400 || strings.Contains(w.Body.String(), "foo") {
+			t.Errorf("Callback %q should be rejected with 400, was %d %s", callback, w.Code, w.Body.String())
+		}
+	}
+
+	w = PerformRequest(r, "GET", "/test")
+	if w.Body.String() != "{\"foo\":\"bar\"}\n" {
+		t.Errorf("Response without callback should be plain JSON, was %s", w.Body.String())
+	}
+}
+
+// TestContextIndentedJSON tests the pretty printed JSON render and the
+// debug-only engine option.
+func TestContextIndentedJSON(t *testing.T) {
+	r := New()
+	r.GET("/indented", func(c *Context) {
+		c.IndentedJSON(200, H{"foo": "bar"})
+	})
+	r.GET("/json", func(c *Context) {
+		c.JSON(200, H{"foo": "bar"})
+	})
+
+	indented := "{\n    \"foo\": \"bar\"\n}"
+	w := PerformRequest(r, "GET", "/indented")
+	if w.Body.String() != indented {
+		t.Errorf("Response should be %q, was %q", indented, w.Body.String())
+	}
+
+	r.IndentJSONInDebug = true
+	w = PerformRequest(r, "GET", "/json")
+	if w.Body.String() != "{\"foo\":\"bar\"}\n" {
+		t.Errorf("JSON should not be indented outside debug mode, was %q", w.Body.String())
+	}
+
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+	w = PerformRequest(r, "GET", "/json")
+	if w.Body.String() != indented {
+		t.Errorf("JSON should be indented in debug mode, was %q", w.Body.String())
+	}
+}
+
+// TestContextPureJSON tests that html characters are not escaped.
+func TestContextPureJSON(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.PureJSON(200, H{"html": "<b>a & b</b>"})
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+	if w.Body.String() != "{\"html\":\"<b>a & b</b>\"}\n" {
+		t.Errorf("HTML should not be escaped, was %s", w.Body.String())
+	}
+}
+
+// TestContextAsciiJSON tests that non-ASCII characters are escaped.
+func TestContextAsciiJSON(t *testing.T) {
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.AsciiJSON(200, H{"lang": "GO语言", "emoji": "😀"})
+	})
+
+	w := PerformRequest(r, "GET", "/test")
+	expected := `{"emoji":"\ud83d\ude00","lang":"GO\u8bed\u8a00"}`
+	if w.Body.String() != expected {
+		t.Errorf("Response should be %s, was %s", expected, w.Body.String())
+	}
+}
+
+// TestContextShouldBind tests that the ShouldBind family returns errors
+// without writing the response.
+func TestContextShouldBind(t *testing.T) {
+	type payload struct {
+		Foo string `json:"foo" xml:"foo" form:"foo" binding:"required"`
+	}
+
+	r := New()
+	r.POST("/json", func(c *Context) {
+		var obj payload
+		if err := c.ShouldBindJSON(&obj); err != nil {
+			c.String(422, "custom: %s", err.Error())
+			return
+		}
+		c.String(200, obj.Foo)
+	})
+	r.POST("/query", func(c *Context) {
+		var obj payload
+		if err := c.ShouldBindQuery(&obj); err != nil {
+			c.String(422, err.Error())
+			return
+		}
+		c.String(200, obj.Foo)
+	})
+	r.POST("/auto", func(c *Context) {
+		var obj payload
+		if err := c.ShouldBind(&obj); err != nil {
+			c.String(422, err.Error())
+			return
+		}
+		if len(c.Errors) != 0 {
+			t.Errorf("ShouldBind should not record errors")
+		}
+		c.String(200, obj.Foo)
+	})
+
+	req, _ := http.NewRequest("POST", "/json", bytes.NewBufferString("{}"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 422 || w.Body.String() != "custom: Required Foo" {
+		t.Errorf("Handler should render its own error, got %d %q", w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("POST", "/query?foo=bar", bytes.NewBufferString("foo=baz"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.Body.String() != "bar" {
+		t.Errorf("Query should be bound regardless of the body, got %d %q", w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("POST", "/auto", bytes.NewBufferString("<payload><foo>bar</foo></payload>"))
+	req.Header.Set("Content-Type", MIMEXML)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.Body.String() != "bar" {
+		t.Errorf("XML should be bound, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestBindingCustomValidation tests custom and cross-field validation rules.
+func TestBindingCustomValidation(t *testing.T) {
+	binding.RegisterValidation("phone", func(field, _ reflect.Value, _ string) bool {
+		phone := field.String()
+		return len(phone) == 11 && strings.Trim(phone, "0123456789") == ""
+	})
+
+	type signup struct {
+		Phone    string `form:"phone" binding:"required,phone"`
+		Password string `form:"password" binding:"required"`
+		Confirm  string `form:"confirm" binding:"eqfield=Password"`
+		Age      int    `form:"age" binding:"min=1,-"`
+	}
+
+	r := New()
+	r.GET("/signup", func(c *Context) {
+		var obj signup
+		if err := c.ShouldBind(&obj); err != nil {
+			c.String(400, err.Error())
+			return
+		}
+		c.String(200, "ok")
+	})
+
+	tests := []struct {
+		query string
+		code  int
+		body  string
+	}{
+		{"phone=13800138000&password=a&confirm=a", 200, "ok"},
+		{"phone=1380&password=a&confirm=a", 400, "Field validation for 'Phone' failed on the 'phone' tag"},
+		{"phone=13800138000&password=a&confirm=b", 400, "Field validation for 'Confirm' failed on the 'eqfield' tag"},
+		{"password=a&confirm=a", 400, "Required Phone"},
+		{"phone=13800138000&password=a&confirm=a&age=0", 200, "ok"},
+	}
+	for _, test := range tests {
+		w := PerformRequest(r, "GET", "/signup?"+test.query)
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s: expected %d %q, got %d %q", test.query, test.code, test.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestBindingValidationErrors tests that every invalid field is reported
+// and that the errors serialize to JSON.
+func TestBindingValidationErrors(t *testing.T) {
+	type address struct {
+		City string `json:"city" binding:"required"`
+		Zip  string `json:"zip"`
+	}
+	type user struct {
+		Name    string  `json:"name" binding:"required"`
+		Email   string  `json:"email" binding:"required"`
+		Address address `json:"address"`
+	}
+
+	r := New()
+	r.POST("/test", func(c *Context) {
+		var obj user
+		err := c.ShouldBindJSON(&obj)
+		errs, ok := err.(binding.ValidationErrors)
+		if !ok {
+			t.Fatalf("Error should be binding.ValidationErrors, was %T", err)
+		}
+		c.JSON(400, H{"errors": errs})
+	})
+
+	req, _ := http.NewRequest("POST", "/test", bytes.NewBufferString(`{"email":""}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	expected := `{"errors":[{"field":"Name","rule":"required"},{"field":"Email","rule":"required"}]}` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("Response should be %s, was %s", expected, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("POST", "/test", bytes.NewBufferString(`{"name":"secret","email":"b","address":{"zip":"1"}}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"field":"Address.City"`) {
+		t.Errorf("Nested field should be reported with its path, was %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "secret") || strings.Contains(w.Body.String(), `"value"`) {
+		t.Errorf("Submitted values should not be serialized, was %s", w.Body.String())
+	}
+}
+
+// TestContextBindUri tests that path parameters are bound with uri tags.
+func TestContextBindUri(t *testing.T) {
+	type repo struct {
+		Org  string `uri:"org" binding:"required"`
+		Repo string `uri:"repo" binding:"required"`
+		ID   int    `uri:"id"`
+	}
+
+	var obj repo
+	r := New()
+	r.GET("/orgs/:org/repos/:repo/issues/:id", func(c *Context) {
+		if c.BindUri(&obj) {
+			c.String(200, "ok")
+		}
+	})
+
+	w := PerformRequest(r, "GET", "/orgs/gin/repos/gin/issues/42")
+	if w.Code != 200 {
+		t.Errorf("Response code should be 200, was %d", w.Code)
+	}
+	if obj.Org != "gin" || obj.Repo != "gin" || obj.ID != 42 {
+		t.Errorf("Unexpected bound value %+v", obj)
+	}
+
+	w = PerformRequest(r, "GET", "/orgs/gin/repos/gin/issues/abc")
+	if w.Code != 400 {
+		t.Errorf("Invalid params should fail with 400, was %d", w.Code)
+	}
+}
+
+// TestContextBindHeader tests that headers are bound with type conversion
+// and required checks.
+func TestContextBindHeader(t *testing.T) {
+	type headers struct {
+		RequestID string `header:"X-Request-Id" binding:"required"`
+		Version   int    `header:"x-api-version"`
+		Debug     bool   `header:"X-Debug"`
+	}
+
+	var obj headers
+	r := New()
+	r.GET("/test", func(c *Context) {
+		obj = headers{}
+		if c.BindHeader(&obj) {
+			c.String(200, "ok")
+		}
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	req.Header.Set("X-Api-Version", "2")
+	req.Header.Set("X-Debug", "true")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Response code should be 200, was %d", w.Code)
+	}
+	if obj.RequestID != "abc" || obj.Version != 2 || !obj.Debug {
+		t.Errorf("Unexpected bound value %+v", obj)
+	}
+
+	w = PerformRequest(r, "GET", "/test")
+	if w.Code != 400 {
+		t.Errorf("Missing required header should fail with 400, was %d", w.Code)
+	}
+}
+
+// TestContextBindQuery tests that only the query string is bound for POST
+// requests carrying a form body.
+func TestContextBindQuery(t *testing.T) {
+	type filter struct {
+		Status string `form:"status" binding:"required"`
+		Page   int    `form:"page"`
+	}
+
+	var obj filter
+	r := New()
+	r.POST("/search", func(c *Context) {
+		obj = filter{}
+		if c.BindQuery(&obj) {
+			c.String(200, "ok")
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/search?status=open&page=2", bytes.NewBufferString("status=closed&page=9"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || obj.Status != "open" || obj.Page != 2 {
+		t.Errorf("Only the query should be bound, got %d %+v", w.Code, obj)
+	}
+
+	req, _ = http.NewRequest("POST", "/search", bytes.NewBufferString("status=closed"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 400 {
+		t.Errorf("Body values should be ignored, response code should be 400, was %d", w.Code)
+	}
+}
+
+// TestBindingStrictJSON tests the engine-level and per-call strict JSON modes.
+func TestBindingStrictJSON(t *testing.T) {
+	r := New()
+	r.POST("/default", func(c *Context) {
+		var obj struct {
+			Foo string `json:"foo"`
+		}
+		if err := c.ShouldBindJSON(&obj); err != nil {
+			c.String(400, err.Error())
+			return
+		}
+		c.String(200, obj.Foo)
+	})
+	r.POST("/strict", func(c *Context) {
+		var obj map[string]interface{}
+		if err := c.ShouldBindWith(&obj, binding.StrictJSON); err != nil {
+			c.String(400, err.Error())
+			return
+		}
+		c.String(200, "%T", obj["id"])
+	})
+
+	perform := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", MIMEJSON)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := perform("/default", `{"foo":"bar","extra":1}`); w.Code != 200 {
+		t.Errorf("Unknown fields should be ignored by default, got %d", w.Code)
+	}
+	if w := perform("/strict", `{"id":9007199254740993}`); w.Body.String() != "json.Number" {
+		t.Errorf("Numbers should be decoded as json.Number, got %s", w.Body.String())
+	}
+
+	r.StrictJSONBinding = true
+	if w := perform("/default", `{"foo":"bar","extra":1}`); w.Code != 400 {
+		t.Errorf("Unknown fields should be rejected in strict mode, got %d", w.Code)
+	}
+}
+
+// TestBindingDefaultValues tests that default tags are applied to absent
+// fields by both form and JSON binders.
+func TestBindingDefaultValues(t *testing.T) {
+	type listParams struct {
+		Page    int      `form:"page" json:"page" default:"1"`
+		Size    int      `form:"size" json:"size" default:"20"`
+		Sort    string   `form:"sort" json:"sort" default:"created"`
+		Fields  []string `form:"fields" json:"fields" default:"id, name"`
+		Verbose bool     `form:"verbose" json:"verbose"`
+	}
+
+	var obj listParams
+	r := New()
+	r.GET("/list", func(c *Context) {
+		obj = listParams{}
+		c.Bind(&obj)
+	})
+	r.POST("/list", func(c *Context) {
+		obj = listParams{}
+		c.Bind(&obj)
+	})
+
+	PerformRequest(r, "GET", "/list?size=50")
+	if obj.Page != 1 || obj.Size != 50 || obj.Sort != "created" || len(obj.Fields) != 2 || obj.Fields[1] != "name" {
+		t.Errorf("Defaults should fill absent query fields, got %+v", obj)
+	}
+
+	req, _ := http.NewRequest("POST", "/list", bytes.NewBufferString(`{"sort":"name"}`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if obj.Page != 1 || obj.Size != 20 || obj.Sort != "name" {
+		t.Errorf("Defaults should fill absent JSON fields, got %+v", obj)
+	}
+}
+
+// TestBindingDefaultValuesCombined tests that the defaults of a binder don't reset the fields
+// bound by an earlier one.
+func TestBindingDefaultValuesCombined(t *testing.T) {
+	type params struct {
+		ID   int    `uri:"id" default:"7"`
+		Name string `json:"name" default:"anon"`
+		Page int    `json:"page" form:"page" default:"1"`
+		Size int    `form:"size" default:"20"`
+	}
+
+	var obj params
+	r := New()
+	r.POST("/items/:id", func(c *Context) {
+		obj = params{}
+		if err := c.ShouldBindJSON(&obj); err != nil {
+			t.Error(err)
+		}
+		if err := c.ShouldBindUri(&obj); err != nil {
+			t.Error(err)
+		}
+		if err := c.ShouldBindQuery(&obj); err != nil {
+			t.Error(err)
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/items/42", bytes.NewBufferString(`{"name":"bob","page":5}`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if obj.ID != 42 || obj.Name != "bob" || obj.Page != 5 || obj.Size != 20 {
+		t.Errorf("Bound fields should be kept across binders, got %+v", obj)
+	}
+}
+
+// TestBindingTimeFields tests time_format, time_utc and time_location tags.
+func TestBindingTimeFields(t *testing.T) {
+	type period struct {
+		From    time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+		To      time.Time `form:"to" time_format:"2006-01-02 15:04" time_location:"Asia/Shanghai"`
+		Since   time.Time `form:"since" time_format:"unix"`
+		Created time.Time `form:"created"`
+	}
+
+	var obj period
+	r := New()
+	r.GET("/test", func(c *Context) {
+		obj = period{}
+		if err := c.ShouldBindQuery(&obj); err != nil {
+			c.String(400, err.Error())
+		}
+	})
+
+	w := PerformRequest(r, "GET", "/test?from=2016-09-05&to=2016-09-06+08:30&since=1473033600&created=2016-09-05T10:00:00Z")
+	if w.Code != 200 {
+		t.Fatalf("Binding should succeed, got %d %s", w.Code, w.Body.String())
+	}
+	if !obj.From.Equal(time.Date(2016, 9, 5, 0, 0, 0, 0, time.UTC)) || obj.From.Location() != time.UTC {
+		t.Errorf("Unexpected From %v", obj.From)
+	}
+	if obj.To.Location().String() != "Asia/Shanghai" || obj.To.Hour() != 8 {
+		t.Errorf("Unexpected To %v", obj.To)
+	}
+	if obj.Since.Unix() != 1473033600 {
+		t.Errorf("Unexpected Since %v", obj.Since)
+	}
+	if !obj.Created.Equal(time.Date(2016, 9, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected Created %v", obj.Created)
+	}
+
+	w = PerformRequest(r, "GET", "/test?from=05/09/2016")
+	if w.Code != 400 {
+		t.Errorf("Invalid dates should fail, got %d", w.Code)
+	}
+}
+
+// TestBindingNestedForm tests nested structs, scalar slices and slices of
+// structs in form binding.
+func TestBindingNestedForm(t *testing.T) {
+	type item struct {
+		SKU      string `form:"sku" binding:"required"`
+		Quantity int    `form:"qty" default:"1"`
+	}
+	type address struct {
+		City string `form:"city"`
+		Zip  string `form:"zip"`
+	}
+	type order struct {
+		IDs      []int    `form:"ids"`
+		Address  address  `form:"address"`
+		Billing  *address `form:"billing"`
+		Shipping *address `form:"shipping"`
+		Items    []item   `form:"items"`
+	}
+
+	var obj order
+	r := New()
+	r.POST("/order", func(c *Context) {
+		obj = order{}
+		if err := c.ShouldBind(&obj); err != nil {
+			c.String(400, err.Error())
+		}
+	})
+
+	body := "ids=1&ids=2&address.city=Chengdu&address.zip=610000&billing.city=Beijing" +
+		"&items[0].sku=A1&items[0].qty=3&items[1].sku=B2"
+	req, _ := http.NewRequest("POST", "/order", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Binding should succeed, got %d %s", w.Code, w.Body.String())
+	}
+	if len(obj.IDs) != 2 || obj.IDs[1] != 2 {
+		t.Errorf("Unexpected IDs %v", obj.IDs)
+	}
+	if obj.Address.City != "Chengdu" || obj.Address.Zip != "610000" {
+		t.Errorf("Unexpected Address %+v", obj.Address)
+	}
+	if obj.Billing == nil || obj.Billing.City != "Beijing" {
+		t.Errorf("Unexpected Billing %+v", obj.Billing)
+	}
+	if obj.Shipping != nil {
+		t.Errorf("Absent pointer structs should stay nil, was %+v", obj.Shipping)
+	}
+	if len(obj.Items) != 2 || obj.Items[0].Quantity != 3 || obj.Items[1].SKU != "B2" || obj.Items[1].Quantity != 1 {
+		t.Errorf("Unexpected Items %+v", obj.Items)
+	}
+}
+
+// TestBindingMultipartFiles tests that uploaded files are bound next to the
+// scalar form fields.
+func TestBindingMultipartFiles(t *testing.T) {
+	type upload struct {
+		Title       string                  `form:"title" binding:"required"`
+		Avatar      *multipart.FileHeader   `form:"avatar" binding:"required"`
+		Attachments []*multipart.FileHeader `form:"attachments"`
+	}
+
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	mw.WriteField("title", "hello")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	fw, _ = mw.CreateFormFile("attachments", "a.txt")
+	fw.Write([]byte("a"))
+	fw, _ = mw.CreateFormFile("attachments", "b.txt")
+	fw.Write([]byte("b"))
+	mw.Close()
+
+	var obj upload
+	r := New()
+	r.POST("/upload", func(c *Context) {
+		if err := c.ShouldBind(&obj); err != nil {
+			c.String(400, err.Error())
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/upload", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Binding should succeed, got %d %s", w.Code, w.Body.String())
+	}
+	if obj.Title != "hello" || obj.Avatar == nil || obj.Avatar.Filename != "avatar.png" {
+		t.Errorf("Unexpected bound value %+v", obj)
+	}
+	if len(obj.Attachments) != 2 || obj.Attachments[1].Filename != "b.txt" {
+		t.Errorf("Unexpected attachments %v", obj.Attachments)
+	}
+}
+
+// TestBindingMultipartMemory tests that Bind keeps Engine.MaxMultipartMemory bytes of the form in memory.
+func TestBindingMultipartMemory(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write(bytes.Repeat([]byte("x"), 3<<19))
+	mw.Close()
+
+	var obj struct {
+		Avatar *multipart.FileHeader `form:"avatar" binding:"required"`
+	}
+	r := New()
+	r.MaxMultipartMemory = 4 << 20
+	r.POST("/upload", func(c *Context) {
+		if c.Bind(&obj) {
+			f, _ := obj.Avatar.Open()
+			defer f.Close()
+			if _, onDisk := f.(*os.File); onDisk {
+				t.Errorf("File under MaxMultipartMemory should be kept in memory")
+			}
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/upload", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 || obj.Avatar == nil || obj.Avatar.Size != 3<<19 {
+		t.Errorf("Binding should succeed, was: %d %+v", w.Code, obj.Avatar)
+	}
+}
+
+type upperBinding struct{}
+
+func (upperBinding) Bind(req *http.Request, obj interface{}) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	*(obj.(*string)) = strings.ToUpper(string(body))
+	return nil
+}
+
+// TestBindingRegistered tests that Bind dispatches to a binding registered for the content type.
+func TestBindingRegistered(t *testing.T) {
+	binding.Register("text/x-upper", upperBinding{})
+	defer binding.Register("text/x-upper", nil)
+
+	var got string
+	r := New()
+	r.POST("/", func(c *Context) {
+		c.Bind(&got)
+	})
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString("hello"))
+	req.Header.Set("Content-Type", "text/x-upper; charset=utf-8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Response code should be 200, was: %d", w.Code)
+	}
+	if got != "HELLO" {
+		t.Errorf("Bound value should be HELLO, was %s", got)
+	}
+}
+
+// TestContextXMLOptions tests the custom root element, attributes and declaration of the XML render.
+func TestContextXMLOptions(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	r := New()
+	r.XMLOptions = render.XMLOptions{RootName: "order", AttrPrefix: "@", Header: true}
+	r.GET("/test", func(c *Context) {
+		c.XML(200, H{"@id": 7, "item": H{"@sku": "a1", "qty": 2}, "note": "x<y"})
+	})
+
+	r.ServeHTTP(w, req)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<order id="7"><item sku="a1"><qty>2</qty></item><note>x&lt;y</note></order>`
+	if w.Body.String() != expected {
+		t.Errorf("Response should be %s, was: %s", expected, w.Body.String())
+	}
+}
+
+// TestContextCSV tests that a slice of structs is rendered as CSV with a generated header.
+func TestContextCSV(t *testing.T) {
+	type row struct {
+		Name   string `csv:"name"`
+		Amount int    `csv:"amount"`
+		Secret string `csv:"-"`
+		Note   *string
+	}
+	note := `say "hi", bye`
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.CSV(200, []row{{"a", 1, "x", nil}, {"b,c", 2, "y", &note}})
+	})
+
+	r.ServeHTTP(w, req)
+
+	expected := "name,amount,Note\na,1,\n\"b,c\",2,\"say \"\"hi\"\", bye\"\n"
+	if w.Body.String() != expected {
+		t.Errorf("Response should be %q, was: %q", expected, w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type should be text/csv, was %s", w.HeaderMap.Get("Content-Type"))
+	}
+}
+
+type countingJSON struct {
+	json.Core
+	encoders int
+}
+
+func (c *countingJSON) NewEncoder(w io.Writer) json.Encoder {
+	c.encoders++
+	return c.Core.NewEncoder(w)
+}
+
+// TestContextJSONCustomCodec tests that c.JSON goes through the configured JSON implementation.
+func TestContextJSONCustomCodec(t *testing.T) {
+	codec := &countingJSON{Core: json.API}
+	SetJSON(codec)
+	defer SetJSON(codec.Core)
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	r := New()
+	r.GET("/test", func(c *Context) {
+		c.JSON(200, H{"foo": "bar"})
+	})
+
+	r.ServeHTTP(w, req)
+
+	if codec.encoders != 1 {
+		t.Errorf("Custom JSON encoder should be used once, was used %d times", codec.encoders)
+	}
+	if w.Body.String() != "{\"foo\":\"bar\"}\n" {
+		t.Errorf("Response should be {\"foo\":\"bar\"}, was: %s", w.Body.String())
+	}
+}
+
+// TestBindingMaps tests binding query, form and JSON data into maps.
+func TestBindingMaps(t *testing.T) {
+	r := New()
+	r.GET("/query", func(c *Context) {
+		var m map[string]string
+		if err := c.ShouldBindQuery(&m); err != nil {
+			t.Fatal(err)
+		}
+		c.String(200, "%s %s %d", m["a"], m["b"], len(m))
+	})
+	r.POST("/form", func(c *Context) {
+		var m map[string][]string
+		if err := c.ShouldBind(&m); err != nil {
+			t.Fatal(err)
+		}
+		c.String(200, "%v", m["tag"])
+	})
+	r.POST("/json", func(c *Context) {
+		var m map[string]interface{}
+		if err := c.ShouldBind(&m); err != nil {
+			t.Fatal(err)
+		}
+		c.String(200, "%v", m["event"])
+	})
+
+	w := PerformRequest(r, "GET", "/query?a=1&a=2&b=x")
+	if w.Body.String() != "1 x 2" {
+		t.Errorf("Query should bind into map[string]string, got %s", w.Body.String())
+	}
+
+	req, _ := http.NewRequest("POST", "/form", bytes.NewBufferString("tag=a&tag=b"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "[a b]" {
+		t.Errorf("Form should bind into map[string][]string, got %s", w.Body.String())
+	}
+
+	req, _ = http.NewRequest("POST", "/json", bytes.NewBufferString(`{"event":"push"}`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "push" {
+		t.Errorf("JSON should bind into map[string]interface{}, got %s", w.Body.String())
+	}
+}
+
+// TestContextHTMLAdapter tests that c.HTML renders through a template engine adapter.
+func TestContextHTMLAdapter(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	r := New()
+	r.HTMLRender = render.HTMLAdapter(render.HTMLExecutorFunc(func(w io.Writer, name string, data interface{}) error {
+		_, err := fmt.Fprintf(w, "<%s>%v</%s>", name, data, name)
+		return err
+	}))
+	r.GET("/test", func(c *Context) {
+		c.HTML(201, "p", "gin")
+	})
+
+	r.ServeHTTP(w, req)
+
+	if w.Code != 201 {
+		t.Errorf("Response code should be 201, was: %d", w.Code)
+	}
+	if w.Body.String() != "<p>gin</p>" {
+		t.Errorf("Response should be <p>gin</p>, was: %s", w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type should be text/html, was %s", w.HeaderMap.Get("Content-Type"))
+	}
+}
+
+type ndjsonRender struct{}
+
+func (ndjsonRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(code)
+	for _, line := range data {
+		if _, err := fmt.Fprintf(w, "{\"n\":%v}\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestContextRenderCustom tests that custom renders go through the same status handling as built-in ones.
+func TestContextRenderCustom(t *testing.T) {
+	var written bool
+	r := New()
+	r.GET("/ndjson", func(c *Context) {
+		c.Render(201, ndjsonRender{}, 1, 2)
+		written = c.Writer.Written()
+	})
+	r.GET("/nocontent", func(c *Context) {
+		c.Render(204, ndjsonRender{}, 1)
+	})
+
+	w := PerformRequest(r, "GET", "/ndjson")
+	if w.Code != 201 || w.Body.String() != "{\"n\":1}\n{\"n\":2}\n" || !written {
+		t.Errorf("Unexpected custom render response %d %q, written %v", w.Code, w.Body.String(), written)
+	}
+
+	w = PerformRequest(r, "GET", "/nocontent")
+	if w.Code != 204 || w.Body.Len() != 0 {
+		t.Errorf("204 responses should have no body, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestContextRenderErrorNoPartialBody tests that a failing template doesn't leak a truncated page.
+func TestContextRenderErrorNoPartialBody(t *testing.T) {
+	r := New()
+	r.SetHTMLTemplate(template.Must(template.New("page").Parse(`<h1>title</h1>{{.Missing}}`)))
+	r.GET("/", func(c *Context) {
+		c.HTML(200, "page", 42)
+	})
+
+	w := PerformRequest(r, "GET", "/")
+
+	if w.Code != 500 {
+		t.Errorf("Response code should be 500, was: %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "title") {
+		t.Errorf("Response should not contain the partial page, was: %s", w.Body.String())
+	}
+}
+
+// TestContextJSONRenderErrorNoHeaders tests that the JSON renders failing to marshal leave
+// the response to the error handling.
+func TestContextJSONRenderErrorNoHeaders(t *testing.T) {
+	r := New()
+	r.GET("/secure", func(c *Context) { c.SecureJSON(201, make(chan int)) })
+	r.GET("/jsonp", func(c *Context) { c.JSONP(201, make(chan int)) })
+	r.GET("/indented", func(c *Context) { c.IndentedJSON(201, make(chan int)) })
+	r.GET("/ascii", func(c *Context) { c.AsciiJSON(201, make(chan int)) })
+
+	for _, path := range []string{"/secure", "/jsonp?callback=cb", "/indented", "/ascii"} {
+		w := PerformRequest(r, "GET", path)
+		if w.Code != 500 || w.Body.Len() != 0 || w.HeaderMap.Get("Content-Type") != "" {
+			t.Errorf("%s should fail with 500 and no body, was: %d %q %q", path, w.Code, w.HeaderMap.Get("Content-Type"), w.Body.String())
+		}
+	}
+}
+
+// TestContextDataConditional tests that c.Data honors the validators set on the response.
+func TestContextDataConditional(t *testing.T) {
+	modtime := time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := New()
+	r.GET("/etag", func(c *Context) {
+		c.SetETag("v1")
+		c.Data(200, "text/plain", []byte("etag"))
+	})
+	r.GET("/modified", func(c *Context) {
+		c.SetLastModified(modtime)
+		c.Data(200, "text/plain", []byte("modified"))
+	})
+
+	perform := func(path, header, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := perform("/etag", "If-None-Match", `"v1"`); w.Code != 304 || w.Body.Len() != 0 {
+		t.Errorf("Matching ETag should be answered with 304, was: %d %s", w.Code, w.Body.String())
+	}
+	if w := perform("/etag", "If-None-Match", `"v0"`); w.Code != 200 || w.Body.String() != "etag" {
+		t.Errorf("Stale ETag should be answered in full, was: %d %s", w.Code, w.Body.String())
+	}
+	if w := perform("/modified", "If-Modified-Since", modtime.Format(http.TimeFormat)); w.Code != 304 {
+		t.Errorf("Unmodified content should be answered with 304, was: %d", w.Code)
+	}
+	if w := perform("/modified", "If-Modified-Since", modtime.Add(-time.Hour).Format(http.TimeFormat)); w.Code != 200 || w.Body.String() != "modified" {
+		t.Errorf("Modified content should be answered in full, was: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestContextDataRange tests that Range requests get the requested bytes of c.Data.
+func TestContextDataRange(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.Data(200, "audio/mpeg", []byte("0123456789"))
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 206 || w.Body.String() != "2345" {
+		t.Errorf("Response should be 206 2345, was: %d %s", w.Code, w.Body.String())
+	}
+	if w.HeaderMap.Get("Content-Range") != "bytes 2-5/10" {
+		t.Errorf("Content-Range should be bytes 2-5/10, was: %s", w.HeaderMap.Get("Content-Range"))
+	}
+	if w.HeaderMap.Get("Content-Type") != "audio/mpeg" {
+		t.Errorf("Content-Type should be audio/mpeg, was: %s", w.HeaderMap.Get("Content-Type"))
+	}
+
+	req.Header.Set("Range", "bytes=20-30")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 416 {
+		t.Errorf("Unsatisfiable range should be answered with 416, was: %d", w.Code)
+	}
+}
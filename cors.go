@@ -0,0 +1,117 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS middleware.
+type CORSConfig struct {
+	// AllowOrigins lists the allowed origins. "*" allows any origin, and a single "*" inside
+	// an origin matches any sequence, e.g. "https://*.example.com".
+	AllowOrigins []string
+	// AllowOriginRegexps are matched against the origins not listed in AllowOrigins.
+	AllowOriginRegexps []*regexp.Regexp
+	// AllowOriginFunc, if set, is asked for the origins matched by nothing else.
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods defaults to GET, POST, PUT, PATCH, DELETE, HEAD and OPTIONS.
+	AllowMethods []string
+	// AllowHeaders defaults to Origin, Content-Length and Content-Type.
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge is how long a preflight result may be cached, not sent when zero.
+	MaxAge time.Duration
+}
+
+// CORS returns a middleware implementing Cross-Origin Resource Sharing. Preflight requests are
+// answered with 204 and abort the chain; use the middleware on the engine so preflights are
+// answered for every route, including the ones without an OPTIONS handler.
+// Requests from origins that aren't allowed get no CORS headers, and preflights a 403.
+func CORS(config CORSConfig) HandlerFunc {
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	}
+	if len(config.AllowHeaders) == 0 {
+		config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type"}
+	}
+	allowAll := false
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+	allowMethods := strings.Join(config.AllowMethods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+
+	return func(c *Context) {
+		origin := c.Request.Header.Get("Origin")
+		if origin == "" {
+			return
+		}
+		preflight := c.Request.Method == "OPTIONS" && c.Request.Header.Get("Access-Control-Request-Method") != ""
+		if !allowAll && !config.allowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(403)
+			}
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		if allowAll && !config.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		header.Set("Access-Control-Allow-Headers", allowHeaders)
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(204)
+	}
+}
+
+func (config *CORSConfig) allowOrigin(origin string) bool {
+	for _, allowed := range config.AllowOrigins {
+		if matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	for _, re := range config.AllowOriginRegexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+}
+
+// matchOrigin matches origin against an allowed origin holding at most one "*" wildcard.
+func matchOrigin(allowed, origin string) bool {
+	index := strings.IndexByte(allowed, '*')
+	if index < 0 {
+		return strings.EqualFold(allowed, origin)
+	}
+	prefix, suffix := allowed[:index], allowed[index+1:]
+	return len(origin) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
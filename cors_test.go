@@ -0,0 +1,76 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func performCORS(r http.Handler, method, origin, requestMethod string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "/users", nil)
+	req.Header.Set("Origin", origin)
+	if requestMethod != "" {
+		req.Header.Set("Access-Control-Request-Method", requestMethod)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestCORS tests simple requests and preflights for a route without OPTIONS handler.
+func TestCORS(t *testing.T) {
+	r := New()
+	r.Use(CORS(CORSConfig{
+		AllowOrigins:       []string{"https://*.example.com"},
+		AllowOriginRegexps: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+		ExposeHeaders:      []string{"X-Total"},
+		AllowCredentials:   true,
+		MaxAge:             time.Hour,
+	}))
+	r.GET("/users", func(c *Context) {
+		c.String(200, "users")
+	})
+
+	w := performCORS(r, "GET", "https://app.example.com", "")
+	if w.Body.String() != "users" || w.HeaderMap.Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("Allowed origin should be echoed, was: %v", w.HeaderMap)
+	}
+	if w.HeaderMap.Get("Access-Control-Expose-Headers") != "X-Total" || w.HeaderMap.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("Unexpected CORS headers: %v", w.HeaderMap)
+	}
+
+	w = performCORS(r, "OPTIONS", "http://localhost:3000", "GET")
+	if w.Code != 204 || w.Body.Len() != 0 {
+		t.Errorf("Preflight should be answered with 204, was: %d %s", w.Code, w.Body.String())
+	}
+	if w.HeaderMap.Get("Access-Control-Allow-Methods") == "" || w.HeaderMap.Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("Unexpected preflight headers: %v", w.HeaderMap)
+	}
+
+	w = performCORS(r, "OPTIONS", "https://evil.com", "GET")
+	if w.Code != 403 || w.HeaderMap.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Preflight from a disallowed origin should be rejected, was: %d %v", w.Code, w.HeaderMap)
+	}
+	w = performCORS(r, "GET", "https://evil.com", "")
+	if w.Code != 200 || w.HeaderMap.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Disallowed origin should get no CORS headers, was: %v", w.HeaderMap)
+	}
+}
+
+// TestCORSAllowAll tests the "*" origin.
+func TestCORSAllowAll(t *testing.T) {
+	r := New()
+	r.Use(CORS(CORSConfig{AllowOrigins: []string{"*"}}))
+	r.GET("/users", func(c *Context) {})
+
+	w := performCORS(r, "GET", "https://any.org", "")
+	if w.HeaderMap.Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Access-Control-Allow-Origin should be *, was: %s", w.HeaderMap.Get("Access-Control-Allow-Origin"))
+	}
+}
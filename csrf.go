@@ -0,0 +1,117 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+const CSRFTokenKey = "csrf_token"
+
+var ErrCSRFToken = errors.New("csrf: missing or invalid token")
+
+// CSRFConfig configures the CSRF middleware.
+type CSRFConfig struct {
+	// CookieName defaults to "_csrf".
+	CookieName string
+	// HeaderName defaults to "X-CSRF-Token".
+	HeaderName string
+	// FieldName is the form field holding the token, "_csrf" by default.
+	FieldName string
+	Secure    bool
+	SameSite  http.SameSite
+	// ErrorHandler answers the rejected requests, by default with 403 Forbidden. It should abort.
+	ErrorHandler func(c *Context)
+}
+
+// CSRF returns a middleware protecting against cross-site request forgery with a double
+// submit cookie: every client gets a random token in a cookie, and the requests with an unsafe
+// method (anything but GET, HEAD, OPTIONS and TRACE) must send it back in the X-CSRF-Token
+// header or the _csrf form field, which another site can't do. Put the token in the pages
+// with c.CSRFToken, or the csrfField template function, see CSRFConfig.FuncMap.
+func CSRF(config CSRFConfig) HandlerFunc {
+	if config.CookieName == "" {
+		config.CookieName = "_csrf"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FieldName == "" {
+		config.FieldName = "_csrf"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *Context) {
+			c.Fail(403, ErrCSRFToken)
+		}
+	}
+	return func(c *Context) {
+		token := ""
+		if cookie, err := c.Request.Cookie(config.CookieName); err == nil && len(cookie.Value) == 43 {
+			token = cookie.Value
+		}
+		if token == "" {
+			b := make([]byte, 32)
+			rand.Read(b)
+			token = base64.RawURLEncoding.EncodeToString(b)
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     config.CookieName,
+				Value:    token,
+				Path:     "/",
+				Secure:   config.Secure,
+				HttpOnly: true,
+				SameSite: config.SameSite,
+			})
+		}
+		c.Set(CSRFTokenKey, token)
+
+		switch c.Request.Method {
+		case "GET", "HEAD", "OPTIONS", "TRACE":
+			return
+		}
+		sent := c.Request.Header.Get(config.HeaderName)
+		if sent == "" {
+			sent = c.Request.PostFormValue(config.FieldName)
+		}
+		if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			config.ErrorHandler(c)
+		}
+	}
+}
+
+// CSRFToken returns the token set by the CSRF middleware, empty without it.
+func (c *Context) CSRFToken() string {
+	if token, err := c.Get(CSRFTokenKey); err == nil {
+		if s, ok := token.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// CSRFFuncMap returns the "csrfField" template function of the default configuration, see
+// CSRFConfig.FuncMap.
+func CSRFFuncMap() template.FuncMap {
+	return CSRFConfig{}.FuncMap()
+}
+
+// FuncMap returns the "csrfField" template function writing the hidden input of a token named
+// after FieldName, called as {{csrfField .CSRFToken}}. Use it with Engine.SetFuncMap.
+func (config CSRFConfig) FuncMap() template.FuncMap {
+	name := config.FieldName
+	if name == "" {
+		name = "_csrf"
+	}
+	return template.FuncMap{
+		"csrfField": func(token string) template.HTML {
+			return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) +
+				`" value="` + template.HTMLEscapeString(token) + `">`)
+		},
+	}
+}
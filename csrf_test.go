@@ -0,0 +1,92 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestCSRF tests that unsafe requests need the token of the cookie.
+func TestCSRF(t *testing.T) {
+	r := New()
+	r.Use(CSRF(CSRFConfig{}))
+	r.SetHTMLTemplate(template.Must(template.New("form").Funcs(CSRFFuncMap()).Parse(`{{csrfField .}}`)))
+	r.GET("/form", func(c *Context) {
+		c.HTML(200, "form", c.CSRFToken())
+	})
+	r.POST("/form", func(c *Context) {
+		c.String(200, "ok")
+	})
+
+	w := PerformRequest(r, "GET", "/form")
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if len(cookies) != 1 || !strings.Contains(w.Body.String(), `value="`+cookies[0].Value+`"`) {
+		t.Fatalf("Form should embed the token of the cookie, was: %s %v", w.Body.String(), cookies)
+	}
+	cookie := cookies[0]
+
+	perform := func(token string, header bool) int {
+		form := url.Values{}
+		if !header {
+			form.Set("_csrf", token)
+		}
+		req, _ := http.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", MIMEPOSTForm)
+		if header {
+			req.Header.Set("X-CSRF-Token", token)
+		}
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := perform(cookie.Value, false); code != 200 {
+		t.Errorf("Form token should be accepted, was: %d", code)
+	}
+	if code := perform(cookie.Value, true); code != 200 {
+		t.Errorf("Header token should be accepted, was: %d", code)
+	}
+	if code := perform("forged", true); code != 403 {
+		t.Errorf("Invalid token should be rejected, was: %d", code)
+	}
+	if code := perform("", false); code != 403 {
+		t.Errorf("Missing token should be rejected, was: %d", code)
+	}
+}
+
+// TestCSRFFieldName tests that the csrfField template function uses the configured field name.
+func TestCSRFFieldName(t *testing.T) {
+	config := CSRFConfig{FieldName: "authenticity_token"}
+	r := New()
+	r.Use(CSRF(config))
+	r.SetHTMLTemplate(template.Must(template.New("form").Funcs(config.FuncMap()).Parse(`{{csrfField .}}`)))
+	r.GET("/form", func(c *Context) {
+		c.HTML(200, "form", c.CSRFToken())
+	})
+	r.POST("/form", func(c *Context) {
+		c.String(200, "ok")
+	})
+
+	w := PerformRequest(r, "GET", "/form")
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if len(cookies) != 1 || !strings.Contains(w.Body.String(), `name="authenticity_token"`) {
+		t.Fatalf("Form should use the configured field name, was: %s", w.Body.String())
+	}
+	form := url.Values{"authenticity_token": {cookies[0].Value}}
+	req, _ := http.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("Form token should be accepted, was: %d", w.Code)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	ErrorTypeInternal = 1 << iota
+	ErrorTypeExternal = 1 << iota
+	// Bind errors are recorded by the Bind methods, which make them external too.
+	ErrorTypeBind = 1 << iota
+	ErrorTypeAll  = 0xffffffff
+
+	// Private errors are meant for logs and monitoring only.
+	ErrorTypePrivate = ErrorTypeInternal
+	// Public errors can be exposed to the client, e.g. by ErrorLogger.
+	ErrorTypePublic = ErrorTypeExternal
+)
+
+// Error is an error collected with c.Error during an http request.
+type Error struct {
+	Err  error
+	Type uint32
+	Meta interface{}
+}
+
+type errorMsgs []*Error
+
+var _ error = &Error{}
+
+// SetType sets the error type flags.
+func (msg *Error) SetType(flags uint32) *Error {
+	msg.Type = flags
+	return msg
+}
+
+// SetMeta sets the error meta data.
+func (msg *Error) SetMeta(data interface{}) *Error {
+	msg.Meta = data
+	return msg
+}
+
+// IsType reports whether the error has any of the given type flags.
+func (msg *Error) IsType(flags uint32) bool {
+	return (msg.Type & flags) > 0
+}
+
+// Error implements the error interface.
+func (msg *Error) Error() string {
+	return msg.Err.Error()
+}
+
+// JSON returns a json friendly representation of the error.
+func (msg *Error) JSON() interface{} {
+	return H{
+		"error": msg.Error(),
+		"meta":  msg.Meta,
+	}
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (msg *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(msg.JSON())
+}
+
+// ByType returns a filtered copy of the errors matching the given type flags.
+func (a errorMsgs) ByType(typ uint32) errorMsgs {
+	if len(a) == 0 {
+		return a
+	}
+	result := make(errorMsgs, 0, len(a))
+	for _, msg := range a {
+		if msg.IsType(typ) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Last returns the last error in the slice, or nil if it is empty.
+func (a errorMsgs) Last() *Error {
+	if length := len(a); length > 0 {
+		return a[length-1]
+	}
+	return nil
+}
+
+// Errors returns the error messages.
+func (a errorMsgs) Errors() []string {
+	if len(a) == 0 {
+		return nil
+	}
+	errorStrings := make([]string, len(a))
+	for i, err := range a {
+		errorStrings[i] = err.Error()
+	}
+	return errorStrings
+}
+
+// JSON returns a json friendly representation of the errors.
+func (a errorMsgs) JSON() interface{} {
+	switch len(a) {
+	case 0:
+		return nil
+	case 1:
+		return a.Last().JSON()
+	default:
+		json := make([]interface{}, len(a))
+		for i, err := range a {
+			json[i] = err.JSON()
+		}
+		return json
+	}
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (a errorMsgs) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.JSON())
+}
+
+func (a errorMsgs) String() string {
+	if len(a) == 0 {
+		return ""
+	}
+	var buffer bytes.Buffer
+	for i, msg := range a {
+		text := fmt.Sprintf("Error #%02d: %s \n     Meta: %v\n", (i + 1), msg.Err, msg.Meta)
+		buffer.WriteString(text)
+	}
+	return buffer.String()
+}
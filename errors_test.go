@@ -0,0 +1,93 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestErrorAccumulation tests that errors are collected with their type
+// and meta data and can be inspected after c.Next().
+func TestErrorAccumulation(t *testing.T) {
+	var errs errorMsgs
+
+	r := New()
+	r.Use(func(c *Context) {
+		c.Next()
+		errs = c.Errors
+	})
+	r.GET("/test", func(c *Context) {
+		c.Error(errors.New("first"))
+		c.Error(errors.New("second"), "meta")
+		c.Error(errors.New("third")).SetType(ErrorTypePrivate).SetMeta(42)
+	})
+
+	PerformRequest(r, "GET", "/test")
+
+	if len(errs) != 3 {
+		t.Fatalf("There should be 3 errors, was %d", len(errs))
+	}
+	if public := errs.ByType(ErrorTypePublic); len(public) != 2 {
+		t.Errorf("There should be 2 public errors, was %d", len(public))
+	}
+	private := errs.ByType(ErrorTypePrivate)
+	if len(private) != 1 || private[0].Meta != 42 {
+		t.Errorf("Unexpected private errors %v", private)
+	}
+	if last := errs.Last(); last.Error() != "third" {
+		t.Errorf("Last error should be third, was %s", last.Error())
+	}
+
+	b, _ := json.Marshal(errs.ByType(ErrorTypePublic))
+	expected := `[{"error":"first","meta":null},{"error":"second","meta":"meta"}]`
+	if string(b) != expected {
+		t.Errorf("JSON should be %s, was %s", expected, b)
+	}
+}
+
+// TestBindErrorType tests that binding failures are recorded as bind errors.
+func TestBindErrorType(t *testing.T) {
+	var errs errorMsgs
+
+	r := New()
+	r.POST("/test", func(c *Context) {
+		var obj struct {
+			Foo string `json:"foo" binding:"required"`
+		}
+		c.Bind(&obj)
+		errs = c.Errors
+	})
+
+	w := PerformRequest(r, "POST", "/test")
+
+	if w.Code != 400 {
+		t.Errorf("Response code should be 400, was %d", w.Code)
+	}
+	if len(errs.ByType(ErrorTypeBind)) != 1 || len(errs.ByType(ErrorTypeExternal)) != 1 {
+		t.Errorf("Binding failure should be recorded as an external bind error, got %v", errs)
+	}
+}
+
+// TestErrorLoggerFormat tests that ErrorLogger writes every error as a JSON array.
+func TestErrorLoggerFormat(t *testing.T) {
+	r := New()
+	r.Use(ErrorLoggerT(ErrorTypePublic))
+	r.GET("/one", func(c *Context) {
+		c.Error(errors.New("first"), "meta")
+	})
+	r.GET("/two", func(c *Context) {
+		c.Error(errors.New("first"))
+		c.Error(errors.New("second")).SetType(ErrorTypePrivate)
+	})
+
+	if w := PerformRequest(r, "GET", "/one"); w.Body.String() != `[{"error":"first","meta":"meta"}]`+"\n" {
+		t.Errorf("Single error should be written as an array, was %s", w.Body.String())
+	}
+	if w := PerformRequest(r, "GET", "/two"); w.Body.String() != `[{"error":"first","meta":null},{"error":"second","meta":null}]`+"\n" {
+		t.Errorf("Every error should be written, was %s", w.Body.String())
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+)
+
+// ETag returns a middleware computing the ETag of the 200 responses to GET and HEAD requests
+// over their buffered body, and answering 304 Not Modified when it matches If-None-Match.
+// weak selects weak validators (W/"..."), suited to bodies that are semantically but not
+// byte-for-byte equivalent. An ETag header set by the handler is kept as is.
+// Streamed responses (the handler calls Flush) are sent without an ETag.
+func ETag(weak bool) HandlerFunc {
+	return func(c *Context) {
+		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+			c.Next()
+			return
+		}
+		original := c.Writer
+		w := newBufferedWriter(original)
+		c.Writer = w
+		defer func() { c.Writer = original }()
+
+		c.Next()
+
+		if w.streaming {
+			return
+		}
+		if w.status != 200 || !w.written {
+			w.flush()
+			return
+		}
+		etag := original.Header().Get("ETag")
+		if etag == "" {
+			sum := sha1.Sum(w.body.Bytes())
+			etag = `"` + hex.EncodeToString(sum[:]) + `"`
+			if weak {
+				etag = "W/" + etag
+			}
+			original.Header().Set("ETag", etag)
+		}
+		if etagMatch(c.Request.Header.Get("If-None-Match"), etag) {
+			writeNotModified(original)
+			return
+		}
+		w.flush()
+	}
+}
+
+// etagMatch implements the weak comparison of If-None-Match (RFC 7232 3.2).
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified answers 304 without the headers describing the omitted body.
+func writeNotModified(w ResponseWriter) {
+	header := w.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	w.WriteHeader(304)
+	w.WriteHeaderNow()
+}
@@ -0,0 +1,57 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestETag tests that a matching If-None-Match is answered with 304.
+func TestETag(t *testing.T) {
+	r := New()
+	r.Use(ETag(false))
+	r.GET("/", func(c *Context) {
+		c.JSON(200, H{"foo": "bar"})
+	})
+	r.GET("/missing", func(c *Context) {
+		c.String(404, "missing")
+	})
+
+	w := PerformRequest(r, "GET", "/")
+	etag := w.HeaderMap.Get("ETag")
+	if w.Code != 200 || !strings.HasPrefix(etag, `"`) || w.Body.String() != "{\"foo\":\"bar\"}\n" {
+		t.Fatalf("First request should be answered in full with an ETag, was: %d %s %s", w.Code, etag, w.Body.String())
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 304 || w.Body.Len() != 0 || w.HeaderMap.Get("ETag") != etag {
+		t.Errorf("Matching request should be answered with 304, was: %d %s", w.Code, w.Body.String())
+	}
+
+	w = PerformRequest(r, "GET", "/missing")
+	if w.Code != 404 || w.Body.String() != "missing" || w.HeaderMap.Get("ETag") != "" {
+		t.Errorf("Errors should be sent as is, was: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestETagWeak tests weak validators.
+func TestETagWeak(t *testing.T) {
+	r := New()
+	r.Use(ETag(true))
+	r.GET("/", func(c *Context) {
+		c.String(200, "hello")
+	})
+
+	w := PerformRequest(r, "GET", "/")
+	if !strings.HasPrefix(w.HeaderMap.Get("ETag"), `W/"`) {
+		t.Errorf("ETag should be weak, was: %s", w.HeaderMap.Get("ETag"))
+	}
+}
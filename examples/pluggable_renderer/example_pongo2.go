@@ -1,48 +1,24 @@
 package main
 
 import (
+	"io"
+
 	"github.com/flosch/pongo2"
 	"github.com/gin-gonic/gin"
-	"net/http"
+	"github.com/gin-gonic/gin/render"
 )
 
-type pongoRender struct {
-	cache map[string]*pongo2.Template
-}
-
-func newPongoRender() *pongoRender {
-	return &pongoRender{map[string]*pongo2.Template{}}
-}
-
-func writeHeader(w http.ResponseWriter, code int, contentType string) {
-	if code >= 0 {
-		w.Header().Set("Content-Type", contentType)
-		w.WriteHeader(code)
-	}
-}
-
-func (p *pongoRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
-	file := data[0].(string)
-	ctx := data[1].(pongo2.Context)
-	var t *pongo2.Template
-
-	if tmpl, ok := p.cache[file]; ok {
-		t = tmpl
-	} else {
-		tmpl, err := pongo2.FromFile(file)
-		if err != nil {
-			return err
-		}
-		p.cache[file] = tmpl
-		t = tmpl
+func pongoExecute(w io.Writer, name string, data interface{}) error {
+	tmpl, err := pongo2.FromCache(name)
+	if err != nil {
+		return err
 	}
-	writeHeader(w, code, "text/html")
-	return t.ExecuteWriter(ctx, w)
+	return tmpl.ExecuteWriter(data.(pongo2.Context), w)
 }
 
 func main() {
 	r := gin.Default()
-	r.HTMLRender = newPongoRender()
+	r.HTMLRender = render.HTMLAdapter(render.HTMLExecutorFunc(pongoExecute))
 
 	r.GET("/index", func(c *gin.Context) {
 		name := c.Request.FormValue("name")
@@ -5,21 +5,32 @@
 package gin
 
 import (
+	"context"
+	"crypto/tls"
 	"html/template"
+	"io/fs"
 	"log"
 	"math"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"third/gin/render"
 	"third/httprouter"
 	"time"
 )
 
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
+// Loopback and private networks are trusted to set forwarded headers by default.
+var defaultTrustedProxies = []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"}
+
 const (
 	AbortIndex            = math.MaxInt8 / 2
 	MIMEJSON              = "application/json"
@@ -27,6 +38,12 @@ const (
 	MIMEXML               = "application/xml"
 	MIMEXML2              = "text/xml"
 	MIMEPlain             = "text/plain"
+	MIMEYAML              = "application/x-yaml"
+	MIMEYAML2             = "application/yaml"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
+	MIMETOML              = "application/toml"
 	MIMEPOSTForm          = "application/x-www-form-urlencoded"
 	MIMEPOSTForm2B        = "application/x-www-form-urlencode" // be compatible with codoon Android. WTF!
 	MIMEMultipartPOSTForm = "multipart/form-data"
@@ -41,12 +58,50 @@ type (
 		HTMLRender         render.Render
 		Default404Body     []byte
 		Default405Body     []byte
+		CacheRequestBody   bool
+		MaxMultipartMemory int64
+		MaxBodySize        int64
+		RemoteIPHeaders    []string
+		SecureJSONPrefix   string
+		IndentJSONInDebug  bool
+		StrictJSONBinding  bool
+		XMLOptions         render.XMLOptions
+		trustedCIDRs       []*net.IPNet
 		pool               sync.Pool
 		allNoRouteNoMethod []HandlerFunc
 		noRoute            []HandlerFunc
 		noMethod           []HandlerFunc
 		router             *httprouter.Router
 		logger             []LoggerInfo
+		funcMap            template.FuncMap
+		delims             render.Delims
+		htmlSets           map[string]render.Render
+		spas               []spaConfig
+		// The timeouts and header size limit of the servers started by the Run methods, see
+		// http.Server. ReadHeaderTimeout is 10 seconds by default, the others are unlimited.
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+		MaxHeaderBytes    int
+		// UseH2C serves HTTP/2 without TLS (h2c with prior knowledge) next to HTTP/1 on the
+		// servers started by the Run methods, for gRPC-web and internal HTTP/2 clients.
+		// It relies on http.Protocols, hence the Go 1.24 minimum of gin.
+		UseH2C bool
+		// GracefulExitTimeout is how long a graceful exit waits for the requests in flight,
+		// DefaultGracefulExitTimeout when zero. The admin API takes a timeout parameter instead.
+		GracefulExitTimeout time.Duration
+		altSvc              string
+		noContextReuse      bool
+		onStart             []func()
+		onShutdown          []func()
+		onShutdownDone      []func()
+		startOnce           sync.Once
+		shutdownOnce        sync.Once
+		shutdownDoneOnce    sync.Once
+		apps                []*Engine
+		serversMu           sync.Mutex
+		servers             map[*http.Server]struct{}
 	}
 
 	HandlerInfo struct {
@@ -68,6 +123,13 @@ func New() *Engine {
 	engine.router = httprouter.New()
 	engine.Default404Body = []byte("404 page not found")
 	engine.Default405Body = []byte("405 method not allowed")
+	engine.MaxMultipartMemory = defaultMultipartMemory
+	engine.ReadHeaderTimeout = 10 * time.Second
+	engine.RemoteIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+	engine.SecureJSONPrefix = "while(1);"
+	if err := engine.SetTrustedProxies(defaultTrustedProxies); err != nil {
+		panic(err)
+	}
 	engine.router.NotFound = engine.handle404
 	engine.router.MethodNotAllowed = engine.handle405
 	engine.pool.New = func() interface{} {
@@ -87,30 +149,141 @@ func Default() *Engine {
 
 func (engine *Engine) LoadHTMLGlob(pattern string) {
 	if IsDebugging() {
+		render.HTMLDebug.SetFuncMap(engine.funcMap)
+		render.HTMLDebug.SetDelims(engine.delims)
 		render.HTMLDebug.AddGlob(pattern)
 		engine.HTMLRender = render.HTMLDebug
 	} else {
-		templ := template.Must(template.ParseGlob(pattern))
+		templ := template.Must(engine.newTemplate().ParseGlob(pattern))
 		engine.SetHTMLTemplate(templ)
 	}
 }
 
+// LoadHTMLFS loads the templates of fsys matched by the patterns, e.g. from an embed.FS
+// so the binary doesn't depend on the templates being on disk. fsys is parsed once, in every mode.
+func (engine *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	templ := template.Must(engine.newTemplate().ParseFS(fsys, patterns...))
+	engine.SetHTMLTemplate(templ)
+}
+
+// LoadHTMLGlobWatch loads the templates like LoadHTMLGlob, then reloads them in every mode
+// whenever a matched file changes, checking the files every interval.
+// Call Stop on the returned watcher to stop polling.
+func (engine *Engine) LoadHTMLGlobWatch(pattern string, interval time.Duration) *render.HTMLWatcher {
+	watcher, err := render.NewHTMLWatcher([]string{pattern}, interval, func() (*template.Template, error) {
+		return engine.newTemplate().ParseGlob(pattern)
+	})
+	if err != nil {
+		panic(err)
+	}
+	engine.HTMLRender = watcher
+	return watcher
+}
+
 func (engine *Engine) LoadHTMLFiles(files ...string) {
 	if IsDebugging() {
+		render.HTMLDebug.SetFuncMap(engine.funcMap)
+		render.HTMLDebug.SetDelims(engine.delims)
 		render.HTMLDebug.AddFiles(files...)
 		engine.HTMLRender = render.HTMLDebug
 	} else {
-		templ := template.Must(template.ParseFiles(files...))
+		templ := template.Must(engine.newTemplate().ParseFiles(files...))
 		engine.SetHTMLTemplate(templ)
 	}
 }
 
+// SetFuncMap sets the functions available to the templates loaded by LoadHTMLGlob and
+// LoadHTMLFiles. It must be called before loading the templates.
+func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
+	engine.funcMap = funcMap
+}
+
+// Delims sets the action delimiters of the templates loaded by LoadHTMLGlob and LoadHTMLFiles,
+// e.g. "[[" and "]]" when the pages embed client side {{ }} templates.
+// It must be called before loading the templates.
+func (engine *Engine) Delims(left, right string) *Engine {
+	engine.delims = render.Delims{Left: left, Right: right}
+	return engine
+}
+
+func (engine *Engine) newTemplate() *template.Template {
+	return template.New("").Delims(engine.delims.Left, engine.delims.Right).Funcs(engine.funcMap)
+}
+
 func (engine *Engine) SetHTMLTemplate(templ *template.Template) {
 	engine.HTMLRender = render.HTMLRender{
 		Template: templ,
 	}
 }
 
+// AddHTMLSet registers an independent template set used by c.HTMLSet(code, set, name, obj),
+// so areas like "admin" and "emails" can reuse template names. r is any HTML render,
+// e.g. a render.HTMLRender, render.HTMLLayouts or the watcher returned by LoadHTMLGlobWatch.
+func (engine *Engine) AddHTMLSet(set string, r render.Render) {
+	if engine.htmlSets == nil {
+		engine.htmlSets = make(map[string]render.Render)
+	}
+	engine.htmlSets[set] = r
+}
+
+// LoadHTMLSetGlob registers the templates matched by pattern as the template set called set.
+func (engine *Engine) LoadHTMLSetGlob(set, pattern string) {
+	templ := template.Must(engine.newTemplate().ParseGlob(pattern))
+	engine.AddHTMLSet(set, render.HTMLRender{Template: templ})
+}
+
+// SetHTMLStreaming renders templ as it executes, flushing every flushSize bytes, see
+// render.HTMLStreamRender. The fallback template, if not empty, closes pages failing mid-stream.
+func (engine *Engine) SetHTMLStreaming(templ *template.Template, flushSize int, fallback string) {
+	engine.HTMLRender = render.HTMLStreamRender{
+		Template:  templ,
+		FlushSize: flushSize,
+		Fallback:  fallback,
+	}
+}
+
+// SetTrustedProxies sets the networks (CIDRs or single IPs) allowed to set the headers listed
+// in RemoteIPHeaders. ClientIP ignores those headers when the peer is not a trusted proxy.
+// Passing nil disables forwarded headers entirely. The middlewares needing the client IP,
+// Logger, RateLimit, IPFilter, Audit and ForwardedFor without arguments, all go through
+// ClientIP so they agree on it.
+func (engine *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs, err := parseCIDRs(proxies)
+	if err != nil {
+		return err
+	}
+	engine.trustedCIDRs = cidrs
+	return nil
+}
+
+// parseCIDRs parses a list of CIDRs or single IPs.
+func parseCIDRs(list []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: s}
+			}
+			if ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	return containsIP(engine.trustedCIDRs, ip)
+}
+
 // Adds handlers for NoRoute. It return a 404 code by default.
 func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
 	engine.noRoute = handlers
@@ -137,7 +310,11 @@ func (engine *Engine) rebuild405Handlers() {
 }
 
 func (engine *Engine) handle404(w http.ResponseWriter, req *http.Request) {
-	c := engine.createContext(w, req, nil, engine.allNoRouteNoMethod)
+	handlers := engine.allNoRouteNoMethod
+	if spa := engine.matchSPA(req.Method, req.URL.Path); spa != nil {
+		handlers = engine.combineHandlers([]HandlerFunc{spa.serve})
+	}
+	c := engine.createContext(w, req, nil, "", handlers)
 	// set 404 by default, useful for logging
 	c.Writer.WriteHeader(404)
 	c.Next()
@@ -152,7 +329,7 @@ func (engine *Engine) handle404(w http.ResponseWriter, req *http.Request) {
 }
 
 func (engine *Engine) handle405(w http.ResponseWriter, req *http.Request) {
-	c := engine.createContext(w, req, nil, engine.allNoRouteNoMethod)
+	c := engine.createContext(w, req, nil, "", engine.allNoRouteNoMethod)
 	// set 405 by default, useful for logging
 	c.Writer.WriteHeader(405)
 	c.Next()
@@ -167,26 +344,277 @@ func (engine *Engine) handle405(w http.ResponseWriter, req *http.Request) {
 }
 
 // ServeHTTP makes the router implement the http.Handler interface.
+// When Engine.MaxBodySize is set, reading more than MaxBodySize bytes of a request body fails.
 func (engine *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if engine.MaxBodySize > 0 && request.Body != nil {
+		request.Body = http.MaxBytesReader(writer, request.Body, engine.MaxBodySize)
+	}
 	engine.router.ServeHTTP(writer, request)
 }
 
+// Run listens on addr and serves HTTP until it fails, or until Shutdown or Close is called,
+// in which case it returns nil.
 func (engine *Engine) Run(addr string) error {
 	debugPrint("Listening and serving HTTP on %s\n", addr)
-	if err := http.ListenAndServe(addr, engine); err != nil {
+	srv := engine.newServer(addr)
+	return engine.serve(srv, srv.ListenAndServe)
+}
+
+// RunTLS is like Run, serving HTTPS with the certificate and key files.
+func (engine *Engine) RunTLS(addr string, cert string, key string) error {
+	debugPrint("Listening and serving HTTPS on %s\n", addr)
+	srv := engine.newServer(addr)
+	return engine.serve(srv, func() error { return srv.ListenAndServeTLS(cert, key) })
+}
+
+// RunTLSConfig is like RunTLS, with the TLS settings of config: minimum version, cipher
+// suites, client CAs... The certificates are taken from config.Certificates, or from
+// config.GetCertificate which can reload them without restarting:
+//
+//	config := &tls.Config{
+//		MinVersion:     tls.VersionTLS12,
+//		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return store.Current(), nil },
+//	}
+//	r.RunTLSConfig(":443", config)
+func (engine *Engine) RunTLSConfig(addr string, config *tls.Config) error {
+	debugPrint("Listening and serving HTTPS on %s\n", addr)
+	srv := engine.newServer(addr)
+	srv.TLSConfig = config
+	return engine.serve(srv, func() error { return srv.ListenAndServeTLS("", "") })
+}
+
+// DualConfig configures RunDual.
+type DualConfig struct {
+	// HTTPAddr and HTTPSAddr are the addresses of the two listeners, e.g. ":80" and ":443".
+	HTTPAddr  string
+	HTTPSAddr string
+	// CertFile and KeyFile are the certificate files, unless TLSConfig holds the certificates.
+	CertFile  string
+	KeyFile   string
+	TLSConfig *tls.Config
+	// Redirect redirects the HTTP requests to HTTPS instead of serving them, with 301 for GET
+	// and HEAD and 308 for the other methods.
+	Redirect bool
+	// HSTSMaxAge, when positive, sends a Strict-Transport-Security header with the HTTPS responses.
+	HSTSMaxAge time.Duration
+}
+
+// RunDual serves HTTP and HTTPS from the engine at once, the HTTP listener redirecting to
+// HTTPS when config.Redirect is set. When a server fails the other one is closed and the
+// error returned; after Shutdown it returns nil once both servers drained their requests.
+func (engine *Engine) RunDual(config DualConfig) error {
+	debugPrint("Listening and serving HTTP on %s and HTTPS on %s\n", config.HTTPAddr, config.HTTPSAddr)
+	httpSrv := engine.newServer(config.HTTPAddr)
+	if config.Redirect {
+		_, port, err := net.SplitHostPort(config.HTTPSAddr)
+		if err != nil {
+			return err
+		}
+		httpSrv.Handler = redirectToHTTPS(port)
+	}
+	httpsSrv := engine.newServer(config.HTTPSAddr)
+	httpsSrv.TLSConfig = config.TLSConfig
+	if config.HSTSMaxAge > 0 {
+		handler := httpsSrv.Handler
+		hsts := "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		httpsSrv.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Strict-Transport-Security", hsts)
+			handler.ServeHTTP(w, req)
+		})
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- engine.serve(httpSrv, httpSrv.ListenAndServe) }()
+	go func() {
+		errs <- engine.serve(httpsSrv, func() error { return httpsSrv.ListenAndServeTLS(config.CertFile, config.KeyFile) })
+	}()
+	err := <-errs
+	if err != nil {
+		httpSrv.Close()
+		httpsSrv.Close()
+	}
+	if e := <-errs; err == nil {
+		err = e
+	}
+	return err
+}
+
+// RunUnix is like Run, listening on the unix domain socket file. A stale socket file left
+// by a previous run is replaced. mode sets the permissions of the socket file, e.g. 0660 to
+// let the group of a proxy connect; zero keeps the ones given by the umask. The socket file
+// is removed when the server stops.
+func (engine *Engine) RunUnix(file string, mode os.FileMode) error {
+	debugPrint("Listening and serving HTTP on unix:/%s\n", file)
+	if info, err := os.Lstat(file); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(file)
+	}
+	ln, err := net.Listen("unix", file)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file)
+	if mode != 0 {
+		if err := os.Chmod(file, mode); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+	return engine.RunListener(ln)
+}
+
+// RunListener is like Run, serving on the given listener, e.g. an in-process listener
+// in tests. The listener is closed when the server stops.
+func (engine *Engine) RunListener(ln net.Listener) error {
+	debugPrint("Listening and serving HTTP on listener %s\n", ln.Addr())
+	srv := engine.newServer("")
+	return engine.serve(srv, func() error { return srv.Serve(ln) })
+}
+
+// RunFd is like Run, serving on the listening socket of the inherited file descriptor fd,
+// e.g. 3 for the first socket passed by systemd socket activation.
+func (engine *Engine) RunFd(fd int) error {
+	debugPrint("Listening and serving HTTP on fd@%d\n", fd)
+	f := os.NewFile(uintptr(fd), "fd@"+strconv.Itoa(fd))
+	ln, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
 		return err
 	}
+	return engine.RunListener(ln)
+}
+
+// Shutdown stops the servers started by the Run methods gracefully: they stop accepting
+// connections and wait for the requests in flight, until ctx is done. The shutdown hooks
+// run around it.
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.startShutdown()
+	var err error
+	for _, srv := range engine.runningServers() {
+		if e := srv.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	engine.finishShutdown()
+	return err
+}
+
+// Close stops the servers started by the Run methods immediately, closing their connections.
+func (engine *Engine) Close() error {
+	var err error
+	for _, srv := range engine.runningServers() {
+		if e := srv.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Handler returns the engine wrapped as the servers started by the Run methods serve it, to
+// mount it on an existing http.Server or mux without calling Run:
+//
+//	srv := &http.Server{Addr: ":8080", Handler: r.Handler()}
+//
+// UseH2C is a setting of the server rather than of the handler: set srv.Protocols with
+// UnencryptedHTTP2 enabled to serve h2c.
+func (engine *Engine) Handler() http.Handler {
+	altSvc := engine.altSvc
+	if altSvc == "" {
+		return engine
+	}
+	// advertise HTTP/3 on the TLS connections, see RunQUIC
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.TLS != nil {
+			w.Header().Set("Alt-Svc", altSvc)
+		}
+		engine.ServeHTTP(w, req)
+	})
+}
+
+func (engine *Engine) newServer(addr string) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           engine.Handler(),
+		ReadTimeout:       engine.ReadTimeout,
+		ReadHeaderTimeout: engine.ReadHeaderTimeout,
+		WriteTimeout:      engine.WriteTimeout,
+		IdleTimeout:       engine.IdleTimeout,
+		MaxHeaderBytes:    engine.MaxHeaderBytes,
+	}
+	if engine.UseH2C {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return srv
+}
+
+// redirectToHTTPS returns a handler redirecting to the HTTPS version of the URLs, on httpsPort
+// unless it is empty or "443". GET and HEAD requests are redirected with 301, the others with
+// 308 so clients keep their method and body.
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		code := http.StatusMovedPermanently
+		if req.Method != "GET" && req.Method != "HEAD" {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), code)
+	})
+}
+
+// advertiseHTTP3 makes the TLS servers started afterwards advertise HTTP/3 on the port of
+// addr with an Alt-Svc header.
+func (engine *Engine) advertiseHTTP3(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	engine.altSvc = `h3=":` + port + `"; ma=86400`
 	return nil
 }
 
-func (engine *Engine) RunTLS(addr string, cert string, key string) error {
-	debugPrint("Listening and serving HTTPS on %s\n", addr)
-	if err := http.ListenAndServeTLS(addr, cert, key, engine); err != nil {
+// serve runs srv with run, tracking it for Shutdown and Close. The start hooks run once
+// srv listens, before it accepts connections.
+func (engine *Engine) serve(srv *http.Server, run func() error) error {
+	srv.BaseContext = func(net.Listener) context.Context {
+		engine.startOnce.Do(func() { runHooks(engine.onStart) })
+		return context.Background()
+	}
+	engine.serversMu.Lock()
+	if engine.servers == nil {
+		engine.servers = make(map[*http.Server]struct{})
+	}
+	engine.servers[srv] = struct{}{}
+	engine.serversMu.Unlock()
+	defer func() {
+		engine.serversMu.Lock()
+		delete(engine.servers, srv)
+		engine.serversMu.Unlock()
+	}()
+
+	if err := run(); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
+func (engine *Engine) runningServers() []*http.Server {
+	engine.serversMu.Lock()
+	defer engine.serversMu.Unlock()
+	servers := make([]*http.Server, 0, len(engine.servers))
+	for srv := range engine.servers {
+		servers = append(servers, srv)
+	}
+	return servers
+}
+
 func (engine *Engine) RigsterHttpHandler(hi HandlerInfo) {
 	switch hi.Method {
 	case "GET":
@@ -212,15 +640,81 @@ func (engine *Engine) HandleSignal(signals ...os.Signal) {
 
 	s := <-sig
 	log.Printf("gin: graceful exit action from signal [%s]", s.String())
-	gracefulExit()
+	engine.gracefulExit(engine.GracefulExitTimeout)
+}
+
+// OnStart registers hooks run once when the first server started by the Run methods is
+// listening, before it accepts connections, e.g. to warm caches. They run in order and
+// must be registered before calling Run.
+func (engine *Engine) OnStart(hooks ...func()) {
+	engine.onStart = append(engine.onStart, hooks...)
+}
+
+// OnShutdown registers hooks run once when a graceful exit or Shutdown starts, before waiting
+// for the requests in flight. They run in order and must be registered before calling Run.
+func (engine *Engine) OnShutdown(hooks ...func()) {
+	engine.onShutdown = append(engine.onShutdown, hooks...)
+}
+
+// OnShutdownDone registers hooks run once when a graceful exit or Shutdown completes, after
+// the requests in flight finished or the timeout expired, e.g. to close the DB pools. They
+// run in order and must be registered before calling Run.
+func (engine *Engine) OnShutdownDone(hooks ...func()) {
+	engine.onShutdownDone = append(engine.onShutdownDone, hooks...)
+}
+
+func runHooks(hooks []func()) {
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// startShutdown runs the shutdown hooks of the engine and of its applications, once.
+func (engine *Engine) startShutdown() {
+	for _, e := range engine.withApps() {
+		e.shutdownOnce.Do(func() { runHooks(e.onShutdown) })
+	}
+}
+
+// finishShutdown runs the shutdown done hooks of the engine and of its applications, once.
+func (engine *Engine) finishShutdown() {
+	for _, e := range engine.withApps() {
+		e.shutdownDoneOnce.Do(func() { runHooks(e.onShutdownDone) })
+	}
+}
+
+// withApps returns the engine followed by the application engines given to UseAdminServer.
+func (engine *Engine) withApps() []*Engine {
+	return append([]*Engine{engine}, engine.apps...)
+}
+
+// gracefulExit is gracefulExit running the shutdown hooks of the engine around it.
+func (engine *Engine) gracefulExit(timeout time.Duration) {
+	engine.startShutdown()
+	gracefulExit(timeout)
+	engine.finishShutdown()
+}
+
+// DefaultGracefulExitTimeout is how long a graceful exit waits for the requests in flight
+// when no timeout is configured.
+const DefaultGracefulExitTimeout = 60 * time.Second
+
+// InFlightRequests returns the number of requests being handled by the routes of the engines.
+func InFlightRequests() int64 {
+	return atomic.LoadInt64(&numReqs)
 }
 
 // graceful exit
 var exitOnce sync.Once
 
-func gracefulExit() {
+// gracefulExit rejects the new requests and waits up to timeout for the ones in flight,
+// logging every second how many remain.
+func gracefulExit(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultGracefulExitTimeout
+	}
 	onceFunc := func() {
-		log.Println("gin: graceful exiting...")
+		log.Printf("gin: graceful exiting, %d requests in flight, timeout %v...", InFlightRequests(), timeout)
 		setExit(true)
 		wait := func() <-chan struct{} {
 			c := make(chan struct{})
@@ -230,20 +724,33 @@ func gracefulExit() {
 			}()
 			return c
 		}
-		select {
-		case <-wait():
-			log.Println("gin: graceful exit OK")
-		case <-time.After(60 * time.Second):
-			log.Println("gin: graceful exit timeout")
+		done := wait()
+		deadline := time.After(timeout)
+		progress := time.NewTicker(time.Second)
+		defer progress.Stop()
+		for {
+			select {
+			case <-done:
+				log.Println("gin: graceful exit OK")
+				return
+			case <-progress.C:
+				log.Printf("gin: graceful exiting, %d requests in flight", InFlightRequests())
+			case <-deadline:
+				log.Printf("gin: graceful exit timeout, %d requests in flight", InFlightRequests())
+				return
+			}
 		}
 	}
 	exitOnce.Do(onceFunc)
 }
 
 // gin admin server, for dynamic set log level, graceful exit, pprof, etc.
-func UseAdminServer(addr string, logger []LoggerInfo, handler []HandlerInfo) *Engine {
+// The shutdown hooks of the application engines apps run on its graceful exits too, see
+// OnShutdown.
+func UseAdminServer(addr string, logger []LoggerInfo, handler []HandlerInfo, apps ...*Engine) *Engine {
 	engine := New()
 	engine.logger = logger
+	engine.apps = apps
 	g := engine.Group("/admin")
 	{
 		// log level
@@ -251,10 +758,11 @@ func UseAdminServer(addr string, logger []LoggerInfo, handler []HandlerInfo) *En
 		g.POST("/set_log_level", engine.setloglevelHandler)
 		// graceful exit
 		g.GET("/gracefulexit", engine.gracefulExitHandler)
+		// maintenance mode
+		g.GET("/maintenance", engine.maintenanceHandler)
+		g.POST("/maintenance", engine.maintenanceHandler)
 		// pprof
-		g.GET("/debug/pprof/", WrapF(pprof.Index))
-		g.GET("/debug/pprof/:name", pprofHandler)
-		g.POST("/debug/pprof/:name", pprofHandler)
+		RegisterPprof(g, "")
 
 	}
 
@@ -316,9 +824,18 @@ func (engine *Engine) setloglevelHandler(c *Context) {
 }
 
 func (engine *Engine) gracefulExitHandler(c *Context) {
+	timeout := engine.GracefulExitTimeout
+	if s := c.Query("timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			codoonRsp(c, "Error", "", "invalid timeout, e.g. 30s")
+			return
+		}
+		timeout = d
+	}
 	log.Printf("gin: graceful exit action from http api [%s]", c.ClientIP())
 	go func() {
-		gracefulExit()
+		engine.gracefulExit(timeout)
 		os.Exit(0)
 
 	}()
@@ -327,6 +844,19 @@ func (engine *Engine) gracefulExitHandler(c *Context) {
 	time.Sleep(1 * time.Second) // wait for 1 second ensure flush data to client
 }
 
+func (engine *Engine) maintenanceHandler(c *Context) {
+	if c.Request.Method == "POST" {
+		on, err := strconv.ParseBool(c.Query("on"))
+		if err != nil {
+			codoonRsp(c, "Error", "", "on must be true or false")
+			return
+		}
+		log.Printf("gin: maintenance mode %t from http api [%s]", on, c.ClientIP())
+		SetMaintenance(on)
+	}
+	codoonRsp(c, "OK", H{"maintenance": IsMaintenance()}, "")
+}
+
 func codoonRsp(c *Context, status string, data interface{}, desc interface{}) {
 	c.JSON(http.StatusOK, H{
 		"Status":      status,
@@ -335,6 +865,20 @@ func codoonRsp(c *Context, status string, data interface{}, desc interface{}) {
 	})
 }
 
+// RegisterPprof mounts the net/http/pprof handlers under prefix in group, "/debug/pprof" when
+// empty, behind the given handlers, e.g. BasicAuth:
+//
+//	gin.RegisterPprof(r.RouterGroup, "/internal/pprof", gin.BasicAuth(accounts))
+func RegisterPprof(group *RouterGroup, prefix string, handlers ...HandlerFunc) {
+	if prefix == "" {
+		prefix = "/debug/pprof"
+	}
+	g := group.Group(prefix, handlers...)
+	g.GET("/", WrapF(pprof.Index))
+	g.GET("/:name", pprofHandler)
+	g.POST("/:name", pprofHandler)
+}
+
 func pprofHandler(c *Context) {
 	// there is a hard-coding in net/http/pprof package, so we rewrite `index` route
 	name := c.Param("name")
@@ -5,13 +5,29 @@
 package gin
 
 import (
-	"io/ioutil"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"html/template"
+	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"testing/fstest"
+	"third/gin/render"
+	"time"
 )
 
 func init() {
@@ -39,7 +55,7 @@ func testRouteOK(method string, t *testing.T) {
 
 	// TEST
 	if passed == false {
-		t.Errorf(method + " route handler was not invoked.")
+		t.Errorf("%s route handler was not invoked.", method)
 	}
 	if w.Code != http.StatusOK {
 		t.Errorf("Status code should be %v, was %d", http.StatusOK, w.Code)
@@ -68,7 +84,7 @@ func testRouteNotOK(method string, t *testing.T) {
 
 	// TEST
 	if passed == true {
-		t.Errorf(method + " route handler was invoked, when it should not")
+		t.Errorf("%s route handler was invoked, when it should not", method)
 	}
 	if w.Code != http.StatusNotFound {
 		// If this fails, it's because httprouter needs to be updated to at least f78f58a0db
@@ -106,7 +122,7 @@ func testRouteNotOK2(method string, t *testing.T) {
 
 	// TEST
 	if passed == true {
-		t.Errorf(method + " route handler was invoked, when it should not")
+		t.Errorf("%s route handler was invoked, when it should not", method)
 	}
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Status code should be %v, was %d. Location: %s", http.StatusMethodNotAllowed, w.Code, w.HeaderMap.Get("Location"))
@@ -127,7 +143,7 @@ func TestRouteNotOK2(t *testing.T) {
 func TestHandleStaticFile(t *testing.T) {
 	// SETUP file
 	testRoot, _ := os.Getwd()
-	f, err := ioutil.TempFile(testRoot, "")
+	f, err := os.CreateTemp(testRoot, "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -192,7 +208,7 @@ func TestHandleHeadToDir(t *testing.T) {
 	// TEST
 	bodyAsString := w.Body.String()
 	if w.Code != 200 {
-		t.Errorf("Response code should be Ok, was: %s", w.Code)
+		t.Errorf("Response code should be Ok, was: %d", w.Code)
 	}
 	if len(bodyAsString) == 0 {
 		t.Errorf("Got empty body instead of file tree")
@@ -204,3 +220,740 @@ func TestHandleHeadToDir(t *testing.T) {
 		t.Errorf("Content-Type should be text/plain, was %s", w.HeaderMap.Get("Content-Type"))
 	}
 }
+
+// TestLoadHTMLGlobFuncMap - ensure the FuncMap is available to the loaded templates
+func TestLoadHTMLGlobFuncMap(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte(`Hello {{upper .}}`), 0644)
+
+	r := New()
+	r.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/", func(c *Context) {
+		c.HTML(200, "hello.tmpl", "gin")
+	})
+
+	w := PerformRequest(r, "GET", "/")
+
+	if w.Body.String() != "Hello GIN" {
+		t.Errorf("Response should be Hello GIN, was: %s", w.Body.String())
+	}
+}
+
+// TestLoadHTMLGlobDelims - ensure custom delimiters leave {{ }} untouched
+func TestLoadHTMLGlobDelims(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "vue.tmpl"), []byte(`[[.]] {{ message }}`), 0644)
+
+	r := New()
+	r.Delims("[[", "]]")
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/", func(c *Context) {
+		c.HTML(200, "vue.tmpl", "gin")
+	})
+
+	w := PerformRequest(r, "GET", "/")
+
+	if w.Body.String() != "gin {{ message }}" {
+		t.Errorf("Response should be gin {{ message }}, was: %s", w.Body.String())
+	}
+}
+
+// TestHTMLLayouts - ensure pages are rendered from their layout and blocks
+func TestHTMLLayouts(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "base.html"), []byte(`<h1>{{block "title" .}}Gin{{end}}</h1>{{template "content" .}}`), 0644)
+	os.WriteFile(filepath.Join(dir, "home.html"), []byte(`{{define "content"}}home {{.}}{{end}}`), 0644)
+	os.WriteFile(filepath.Join(dir, "about.html"), []byte(`{{define "title"}}About{{end}}{{define "content"}}about {{.}}{{end}}`), 0644)
+
+	layouts := render.NewHTMLLayouts()
+	layouts.AddFromFiles("home", filepath.Join(dir, "base.html"), filepath.Join(dir, "home.html"))
+	layouts.AddFromFiles("about", filepath.Join(dir, "base.html"), filepath.Join(dir, "about.html"))
+
+	r := New()
+	r.HTMLRender = layouts
+	r.GET("/:page", func(c *Context) {
+		c.HTML(200, c.Params.ByName("page"), "gin")
+	})
+
+	if w := PerformRequest(r, "GET", "/home"); w.Body.String() != "<h1>Gin</h1>home gin" {
+		t.Errorf("Response should be <h1>Gin</h1>home gin, was: %s", w.Body.String())
+	}
+	if w := PerformRequest(r, "GET", "/about"); w.Body.String() != "<h1>About</h1>about gin" {
+		t.Errorf("Response should be <h1>About</h1>about gin, was: %s", w.Body.String())
+	}
+	if w := PerformRequest(r, "GET", "/missing"); w.Code != 500 {
+		t.Errorf("Status code should be 500 for an unknown page, was: %d", w.Code)
+	}
+}
+
+// TestLoadHTMLGlobWatch - ensure changed templates are reloaded outside debug mode
+func TestLoadHTMLGlobWatch(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "page.tmpl")
+	os.WriteFile(file, []byte(`v1 {{.}}`), 0644)
+
+	r := New()
+	watcher := r.LoadHTMLGlobWatch(filepath.Join(dir, "*.tmpl"), 0)
+	defer watcher.Stop()
+	r.GET("/", func(c *Context) {
+		c.HTML(200, "page.tmpl", "gin")
+	})
+
+	if w := PerformRequest(r, "GET", "/"); w.Body.String() != "v1 gin" {
+		t.Errorf("Response should be v1 gin, was: %s", w.Body.String())
+	}
+
+	os.WriteFile(file, []byte(`{{.}`), 0644)
+	if err := watcher.Reload(); err == nil || watcher.Err() == nil {
+		t.Error("Reloading a broken template should fail")
+	}
+	if w := PerformRequest(r, "GET", "/"); w.Body.String() != "v1 gin" {
+		t.Errorf("Broken templates should keep the previous ones, was: %s", w.Body.String())
+	}
+
+	os.WriteFile(file, []byte(`version 2 {{.}}`), 0644)
+	if err := watcher.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if w := PerformRequest(r, "GET", "/"); w.Body.String() != "version 2 gin" {
+		t.Errorf("Response should be version 2 gin, was: %s", w.Body.String())
+	}
+}
+
+// TestLoadHTMLFS - ensure templates can be loaded from a fs.FS
+func TestLoadHTMLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/index.tmpl": &fstest.MapFile{Data: []byte(`index {{.}}`)},
+		"templates/other.txt":  &fstest.MapFile{Data: []byte(`other`)},
+	}
+
+	r := New()
+	r.LoadHTMLFS(fsys, "templates/*.tmpl")
+	r.GET("/", func(c *Context) {
+		c.HTML(200, "index.tmpl", "gin")
+	})
+
+	if w := PerformRequest(r, "GET", "/"); w.Body.String() != "index gin" {
+		t.Errorf("Response should be index gin, was: %s", w.Body.String())
+	}
+}
+
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes++
+}
+
+// TestHTMLStreaming - ensure streamed pages are flushed and closed by the fallback on failure
+func TestHTMLStreaming(t *testing.T) {
+	templ := template.Must(template.New("").Parse(
+		`{{define "rows"}}{{range .}}<tr>{{.}}</tr>{{end}}{{end}}` +
+			`{{define "broken"}}<p>{{.Missing}}</p>{{end}}` +
+			`{{define "error"}}<div class="error">{{.}}</div>{{end}}`))
+
+	r := New()
+	r.SetHTMLStreaming(templ, 16, "error")
+	r.GET("/rows", func(c *Context) {
+		c.HTML(200, "rows", []int{1, 2, 3, 4, 5})
+	})
+	r.GET("/broken", func(c *Context) {
+		c.HTML(200, "broken", 42)
+	})
+
+	req, _ := http.NewRequest("GET", "/rows", nil)
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "<tr>1</tr><tr>2</tr><tr>3</tr><tr>4</tr><tr>5</tr>" {
+		t.Errorf("Unexpected streamed page: %s", w.Body.String())
+	}
+	if w.flushes < 2 {
+		t.Errorf("Page should be flushed while streaming, flushed %d times", w.flushes)
+	}
+
+	req, _ = http.NewRequest("GET", "/broken", nil)
+	w = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || !strings.HasPrefix(w.Body.String(), "<p><div class=\"error\">") {
+		t.Errorf("Failing page should be closed by the fallback, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestHTMLSets - ensure template sets are independent
+func TestHTMLSets(t *testing.T) {
+	r := New()
+	r.AddHTMLSet("admin", render.HTMLRender{Template: template.Must(template.New("index").Parse(`admin {{.}}`))})
+	r.AddHTMLSet("public", render.HTMLRender{Template: template.Must(template.New("index").Parse(`public {{.}}`))})
+	r.GET("/:set", func(c *Context) {
+		c.HTMLSet(200, c.Params.ByName("set"), "index", "gin")
+	})
+
+	if w := PerformRequest(r, "GET", "/admin"); w.Body.String() != "admin gin" {
+		t.Errorf("Response should be admin gin, was: %s", w.Body.String())
+	}
+	if w := PerformRequest(r, "GET", "/public"); w.Body.String() != "public gin" {
+		t.Errorf("Response should be public gin, was: %s", w.Body.String())
+	}
+	if w := PerformRequest(r, "GET", "/emails"); w.Code != 500 {
+		t.Errorf("Status code should be 500 for an unknown set, was: %d", w.Code)
+	}
+}
+
+// TestServeSPA - ensure unknown paths fall back to index.html outside the excluded prefixes
+func TestServeSPA(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644)
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("js"), 0644)
+
+	r := New()
+	r.ServeSPA("/", dir, "/api")
+	r.GET("/api/users", func(c *Context) {
+		c.String(200, "users")
+	})
+
+	tests := []struct {
+		path, body string
+		code       int
+	}{
+		{"/app.js", "js", 200},
+		{"/settings/profile", "index", 200},
+		{"/api/users", "users", 200},
+		{"/api/unknown", "404 page not found", 404},
+	}
+	for _, test := range tests {
+		w := PerformRequest(r, "GET", test.path)
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s should answer %d %s, was: %d %s", test.path, test.code, test.body, w.Code, w.Body.String())
+		}
+	}
+	if w := PerformRequest(r, "POST", "/settings"); w.Code != 404 {
+		t.Errorf("Only GET and HEAD should fall back to the application, POST was: %d", w.Code)
+	}
+}
+
+// TestRegisterPprof tests that the pprof handlers are mounted behind the given middlewares.
+func TestRegisterPprof(t *testing.T) {
+	r := New()
+	RegisterPprof(r.RouterGroup, "/internal/pprof", BasicAuth(Accounts{"admin": "secret"}))
+
+	if w := PerformRequest(r, "GET", "/internal/pprof/"); w.Code != 401 {
+		t.Errorf("pprof should be behind the middlewares, was: %d", w.Code)
+	}
+	req, _ := http.NewRequest("GET", "/internal/pprof/goroutine?debug=1", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Errorf("Profile should be served, was: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// freeAddr returns a local address free to listen on.
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// waitServing waits for a server of the engine to accept connections on addr.
+func waitServing(t *testing.T, r *Engine, addr string) {
+	for i := 0; i < 100; i++ {
+		if len(r.runningServers()) > 0 {
+			if conn, err := net.Dial("tcp", addr); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Server should be listening on %s", addr)
+}
+
+// TestShutdown tests that Shutdown waits for the requests in flight and ends Run.
+func TestShutdown(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	r := New()
+	r.GET("/slow", func(c *Context) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		c.String(200, "done")
+	})
+	done := make(chan error, 1)
+	go func() { done <- r.Run(addr) }()
+	waitServing(t, r, addr)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+	<-started
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown should succeed, was: %v", err)
+	}
+	if b := <-body; b != "done" {
+		t.Errorf("Request in flight should complete, was: %s", b)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Run should return nil after Shutdown, was: %v", err)
+	}
+}
+
+// TestClose tests that Close ends Run.
+func TestClose(t *testing.T) {
+	addr := freeAddr(t)
+	r := New()
+	done := make(chan error, 1)
+	go func() { done <- r.Run(addr) }()
+	waitServing(t, r, addr)
+
+	r.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Run should return nil after Close, was: %v", err)
+	}
+}
+
+// TestInFlightRequests tests the count of the requests being handled.
+func TestInFlightRequests(t *testing.T) {
+	var inFlight int64
+	r := New()
+	r.GET("/", func(c *Context) {
+		inFlight = InFlightRequests()
+	})
+	PerformRequest(r, "GET", "/")
+	if inFlight < 1 || InFlightRequests() != 0 {
+		t.Errorf("Request should be counted while handled, was: %d then %d", inFlight, InFlightRequests())
+	}
+}
+
+// TestRunUnix tests serving on a unix socket with its permissions, and its cleanup.
+func TestRunUnix(t *testing.T) {
+	dir, err := os.MkdirTemp("", "unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "gin.sock")
+
+	r := New()
+	r.GET("/", func(c *Context) { c.String(200, "unix") })
+	done := make(chan error, 1)
+	go func() { done <- r.RunUnix(file, 0660) }()
+	for i := 0; i < 100 && len(r.runningServers()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial("unix", file)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatalf("Request on the socket should succeed, was: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "unix" {
+		t.Errorf("Response should be unix, was: %s", body)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0660 {
+		t.Errorf("Socket should have the given permissions, was: %v %v", info, err)
+	}
+
+	r.Shutdown(context.Background())
+	if err := <-done; err != nil {
+		t.Errorf("RunUnix should return nil after Shutdown, was: %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("Socket file should be removed, was: %v", err)
+	}
+}
+
+// TestLifecycleHooks tests that the start hooks run once before serving and the shutdown
+// hooks around the graceful exit.
+func TestLifecycleHooks(t *testing.T) {
+	defer func() {
+		exitOnce = sync.Once{}
+		setExit(false)
+	}()
+	var events []string
+	r := New()
+	r.GET("/", func(c *Context) { c.String(200, strings.Join(events, ",")) })
+	r.OnStart(func() { events = append(events, "start") })
+	r.OnShutdown(func() { events = append(events, "shutdown") })
+	r.OnShutdownDone(func() { events = append(events, "done") })
+
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- r.Run(addr) }()
+	waitServing(t, r, addr)
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "start" {
+		t.Errorf("Start hooks should run before serving, was: %s", body)
+	}
+
+	r.gracefulExit(time.Second)
+	r.gracefulExit(time.Second)
+	r.Close()
+	<-done
+	if strings.Join(events, ",") != "start,shutdown,done" {
+		t.Errorf("Hooks should run once in order, was: %v", events)
+	}
+}
+
+// TestLifecycleHooksShutdown tests that Shutdown and the graceful exits of the admin server
+// run the shutdown hooks of the application engines.
+func TestLifecycleHooksShutdown(t *testing.T) {
+	defer func() {
+		exitOnce = sync.Once{}
+		setExit(false)
+	}()
+	var events []string
+	r := New()
+	r.OnShutdown(func() { events = append(events, "shutdown") })
+	r.OnShutdownDone(func() { events = append(events, "done") })
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- r.Run(addr) }()
+	waitServing(t, r, addr)
+	r.Shutdown(context.Background())
+	<-done
+	if strings.Join(events, ",") != "shutdown,done" {
+		t.Errorf("Shutdown should run the hooks, was: %v", events)
+	}
+
+	events = nil
+	app := New()
+	app.OnShutdown(func() { events = append(events, "shutdown") })
+	app.OnShutdownDone(func() { events = append(events, "done") })
+	admin := UseAdminServer(freeAddr(t), nil, nil, app)
+	defer admin.Close()
+	admin.gracefulExit(time.Second)
+	if strings.Join(events, ",") != "shutdown,done" {
+		t.Errorf("Admin graceful exit should run the hooks of the application, was: %v", events)
+	}
+}
+
+// TestRunListener tests serving on a given listener and on an inherited file descriptor.
+func TestRunListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.GET("/", func(c *Context) { c.String(200, "listener") })
+	done := make(chan error, 1)
+	go func() { done <- r.RunListener(ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("Request on the listener should succeed, was: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "listener" {
+		t.Errorf("Response should be listener, was: %s", body)
+	}
+	r.Close()
+	if err := <-done; err != nil {
+		t.Errorf("RunListener should return nil after Close, was: %v", err)
+	}
+
+	ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	// RunFd takes the descriptor over, give it its own
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { done <- r.RunFd(fd) }()
+	waitServing(t, r, addr)
+	r.Close()
+	if err := <-done; err != nil {
+		t.Errorf("RunFd should return nil after Close, was: %v", err)
+	}
+}
+
+// TestUseH2C tests serving HTTP/2 without TLS.
+func TestUseH2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.UseH2C = true
+	r.GET("/", func(c *Context) { c.String(200, c.Request.Proto) })
+	go r.RunListener(ln)
+	defer r.Close()
+
+	transport := &http.Transport{Protocols: new(http.Protocols)}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	resp, err := (&http.Client{Transport: transport}).Get("http://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("h2c request should succeed, was: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("Request should be served over HTTP/2, was: %s", body)
+	}
+	resp, err = http.Get("http://" + ln.Addr().String() + "/")
+	if err != nil || resp.Proto != "HTTP/1.1" {
+		t.Fatalf("HTTP/1 should still be served, was: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// TestAdvertiseHTTP3 tests the Alt-Svc header of the TLS responses.
+func TestAdvertiseHTTP3(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {})
+	if err := r.advertiseHTTP3(":8443"); err != nil {
+		t.Fatal(err)
+	}
+	handler := r.newServer(":8443").Handler
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if altSvc := w.HeaderMap.Get("Alt-Svc"); altSvc != "" {
+		t.Errorf("HTTP/3 should not be advertised without TLS, was: %q", altSvc)
+	}
+	req.TLS = &tls.ConnectionState{}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if altSvc := w.HeaderMap.Get("Alt-Svc"); altSvc != `h3=":8443"; ma=86400` {
+		t.Errorf("HTTP/3 should be advertised over TLS, was: %q", altSvc)
+	}
+}
+
+// TestHandler tests mounting the wrapped engine on another server.
+func TestHandler(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) { c.String(200, "embedded") })
+	if err := r.advertiseHTTP3(":8443"); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewTLSServer(r.Handler())
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "embedded" {
+		t.Errorf("Response should be embedded, was: %s", body)
+	}
+	if altSvc := resp.Header.Get("Alt-Svc"); altSvc != `h3=":8443"; ma=86400` {
+		t.Errorf("Handler should include the Alt-Svc wrapping, was: %q", altSvc)
+	}
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gin test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// TestRunTLSConfig tests serving HTTPS with a custom TLS configuration.
+func TestRunTLSConfig(t *testing.T) {
+	cert, pool := testCertificate(t)
+	addr := freeAddr(t)
+	r := New()
+	r.GET("/", func(c *Context) { c.String(200, "tls") })
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunTLSConfig(addr, &tls.Config{
+			MinVersion:     tls.VersionTLS13,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &cert, nil },
+		})
+	}()
+	waitServing(t, r, addr)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatalf("HTTPS request should succeed, was: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("TLS 1.3 should be negotiated, was: %x", resp.TLS.Version)
+	}
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MaxVersion: tls.VersionTLS12}}}
+	if _, err := client.Get("https://" + addr + "/"); err == nil {
+		t.Errorf("TLS 1.2 should be refused")
+	}
+	r.Close()
+	if err := <-done; err != nil {
+		t.Errorf("RunTLSConfig should return nil after Close, was: %v", err)
+	}
+}
+
+// TestRedirectToHTTPS tests the redirection of the HTTP requests.
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		method, url, port, location string
+		code                        int
+	}{
+		{"GET", "http://example.com/a?b=c", "", "https://example.com/a?b=c", 301},
+		{"GET", "http://example.com:8080/", "8443", "https://example.com:8443/", 301},
+		{"POST", "http://example.com/form", "443", "https://example.com/form", 308},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		w := httptest.NewRecorder()
+		redirectToHTTPS(test.port).ServeHTTP(w, req)
+		if w.Code != test.code || w.HeaderMap.Get("Location") != test.location {
+			t.Errorf("%s %s should redirect to %s with %d, was: %s %d", test.method, test.url, test.location, test.code,
+				w.HeaderMap.Get("Location"), w.Code)
+		}
+	}
+}
+
+// TestRunDual tests serving HTTPS with HSTS and redirecting HTTP to it.
+func TestRunDual(t *testing.T) {
+	cert, pool := testCertificate(t)
+	httpAddr, httpsAddr := freeAddr(t), freeAddr(t)
+	r := New()
+	r.GET("/", func(c *Context) { c.String(200, "secure") })
+	release := make(chan struct{})
+	r.GET("/slow", func(c *Context) {
+		<-release
+		c.String(200, "drained")
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunDual(DualConfig{
+			HTTPAddr:   httpAddr,
+			HTTPSAddr:  httpsAddr,
+			TLSConfig:  &tls.Config{Certificates: []tls.Certificate{cert}},
+			Redirect:   true,
+			HSTSMaxAge: time.Hour,
+		})
+	}()
+	waitServing(t, r, httpAddr)
+	waitServing(t, r, httpsAddr)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("http://" + httpAddr + "/")
+	if err != nil {
+		t.Fatalf("Request should be redirected to HTTPS, was: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.TLS == nil || string(body) != "secure" || resp.Header.Get("Strict-Transport-Security") != "max-age=3600" {
+		t.Errorf("Redirected request should be served over HTTPS with HSTS, was: %s %v", body, resp.Header)
+	}
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := client.Get("https://" + httpsAddr + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		slow <- string(body)
+	}()
+	for InFlightRequests() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+	}()
+	r.Shutdown(context.Background())
+	if body := <-slow; body != "drained" {
+		t.Errorf("HTTPS requests in flight should be drained, was: %s", body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("RunDual should return nil after Shutdown, was: %v", err)
+	}
+}
+
+// TestServerTimeouts tests that the servers get the timeouts of the engine.
+func TestServerTimeouts(t *testing.T) {
+	r := New()
+	r.ReadTimeout = time.Second
+	r.WriteTimeout = 2 * time.Second
+	r.IdleTimeout = 3 * time.Second
+	r.MaxHeaderBytes = 4096
+	srv := r.newServer(":8080")
+	if srv.ReadTimeout != time.Second || srv.ReadHeaderTimeout != 10*time.Second || srv.WriteTimeout != 2*time.Second ||
+		srv.IdleTimeout != 3*time.Second || srv.MaxHeaderBytes != 4096 {
+		t.Errorf("Server should get the timeouts of the engine, was: %+v", srv)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type (
+	// HealthCheck reports whether a dependency is usable, e.g. by pinging a database.
+	// It should return once ctx is done.
+	HealthCheck func(ctx context.Context) error
+
+	// Health serves the liveness and readiness probes of the application.
+	Health struct {
+		timeout time.Duration
+		mu      sync.RWMutex
+		names   []string
+		checks  map[string]HealthCheck
+	}
+)
+
+var errHealthTimeout = errors.New("timeout")
+
+// NewHealth returns a Health running each readiness check with the given timeout,
+// 5 seconds when zero.
+func NewHealth(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Health{timeout: timeout, checks: make(map[string]HealthCheck)}
+}
+
+// Add registers the readiness check called name, replacing the one with the same name.
+func (h *Health) Add(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+	}
+	h.checks[name] = check
+}
+
+// Register mounts the liveness probe at /livez and the readiness probe at /readyz in group.
+func (h *Health) Register(group *RouterGroup) {
+	group.GET("/livez", h.Liveness())
+	group.GET("/readyz", h.Readiness())
+}
+
+// Liveness returns the handler of the liveness probe, answering 200 while the process serves
+// requests.
+func (h *Health) Liveness() HandlerFunc {
+	return func(c *Context) {
+		c.JSON(200, H{"status": "ok"})
+	}
+}
+
+// Readiness returns the handler of the readiness probe. It runs the checks concurrently and
+// answers 200 when all of them pass, and 503 otherwise with the error of each failing check:
+//
+//	{"status": "fail", "checks": {"db": "ok", "billing": "timeout"}}
+//
+// It fails as soon as a graceful exit starts, so the load balancers stop sending requests.
+func (h *Health) Readiness() HandlerFunc {
+	return func(c *Context) {
+		if isExiting() {
+			c.JSON(503, H{"status": "exiting"})
+			return
+		}
+		h.mu.RLock()
+		names := append([]string(nil), h.names...)
+		checks := make([]HealthCheck, len(names))
+		for i, name := range names {
+			checks[i] = h.checks[name]
+		}
+		h.mu.RUnlock()
+
+		errs := make([]error, len(checks))
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func(i int, check HealthCheck) {
+				defer wg.Done()
+				errs[i] = h.run(c.Request.Context(), check)
+			}(i, check)
+		}
+		wg.Wait()
+
+		status, code := "ok", 200
+		results := H{}
+		for i, name := range names {
+			if errs[i] != nil {
+				status, code = "fail", 503
+				results[name] = errs[i].Error()
+			} else {
+				results[name] = "ok"
+			}
+		}
+		c.JSON(code, H{"status": status, "checks": results})
+	}
+}
+
+// run calls check with the timeout, not waiting for the checks ignoring their context.
+func (h *Health) run(ctx context.Context, check HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			return errHealthTimeout
+		}
+		return err
+	case <-ctx.Done():
+		return errHealthTimeout
+	}
+}
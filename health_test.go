@@ -0,0 +1,40 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHealth tests the liveness and readiness probes.
+func TestHealth(t *testing.T) {
+	health := NewHealth(10 * time.Millisecond)
+	health.Add("db", func(ctx context.Context) error { return nil })
+	r := New()
+	health.Register(r.RouterGroup)
+
+	if w := PerformRequest(r, "GET", "/livez"); w.Code != 200 {
+		t.Errorf("Liveness should pass, was: %d", w.Code)
+	}
+	w := PerformRequest(r, "GET", "/readyz")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"db":"ok"`) {
+		t.Errorf("Readiness should pass, was: %d %s", w.Code, w.Body.String())
+	}
+
+	health.Add("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+	health.Add("billing", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	w = PerformRequest(r, "GET", "/readyz")
+	body := w.Body.String()
+	if w.Code != 503 || !strings.Contains(body, `"cache":"connection refused"`) || !strings.Contains(body, `"billing":"timeout"`) {
+		t.Errorf("Readiness should fail with the errors of the checks, was: %d %s", w.Code, body)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	LocaleKey     = "locale"
+	TranslatorKey = "translator"
+)
+
+// Translator holds the message catalogs of the supported locales.
+// Messages are looked up in the requested locale, then its base language ("pt" for "pt-BR"),
+// then the fallback locale; a missing message translates to its key.
+type Translator struct {
+	fallback string
+	mu       sync.RWMutex
+	catalogs map[string]map[string]string
+}
+
+func NewTranslator(fallback string) *Translator {
+	return &Translator{
+		fallback: normalizeLocale(fallback),
+		catalogs: make(map[string]map[string]string),
+	}
+}
+
+// AddMessages adds messages to the catalog of locale. A message is a fmt format string.
+func (t *Translator) AddMessages(locale string, messages map[string]string) {
+	locale = normalizeLocale(locale)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	catalog, ok := t.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		t.catalogs[locale] = catalog
+	}
+	for key, message := range messages {
+		catalog[key] = message
+	}
+}
+
+// LoadGlob loads the JSON catalogs matched by pattern, each file holding a flat object
+// of messages for the locale named by the file, e.g. "locales/pt-BR.json".
+func (t *Translator) LoadGlob(pattern string) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		locale := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		t.AddMessages(locale, messages)
+	}
+	return nil
+}
+
+// Translate returns the message key of locale formatted with args.
+func (t *Translator) Translate(locale, key string, args ...interface{}) string {
+	message, ok := t.lookup(normalizeLocale(locale), key)
+	if !ok {
+		message = key
+	}
+	return formatMessage(message, args)
+}
+
+// formatMessage formats message with args. It takes args as a slice so that vet doesn't
+// check the keys given to T and Translate as format strings.
+func formatMessage(message string, args []interface{}) string {
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+func (t *Translator) lookup(locale, key string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, candidate := range []string{locale, baseLanguage(locale), t.fallback} {
+		if message, ok := t.catalogs[candidate][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Match returns the first of the candidate locales, or their base language, having a catalog.
+// It returns the fallback locale when none has.
+func (t *Translator) Match(candidates ...string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, candidate := range candidates {
+		candidate = normalizeLocale(candidate)
+		if _, ok := t.catalogs[candidate]; ok {
+			return candidate
+		}
+		if _, ok := t.catalogs[baseLanguage(candidate)]; ok {
+			return baseLanguage(candidate)
+		}
+	}
+	return t.fallback
+}
+
+// FuncMap returns the "T" template function, called as {{T .Locale "key" args...}}.
+// Use it with Engine.SetFuncMap.
+func (t *Translator) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"T": t.Translate,
+	}
+}
+
+// I18n returns a middleware detecting the request locale among the catalogs of t, from the
+// cookie called cookieName if not empty then from the Accept-Language header.
+// The locale and the translator are stored under LocaleKey and TranslatorKey, see c.T.
+func I18n(t *Translator, cookieName string) HandlerFunc {
+	return func(c *Context) {
+		c.Set(LocaleKey, t.Match(localeCandidates(c, "", cookieName)...))
+		c.Set(TranslatorKey, t)
+	}
+}
+
+// LocaleConfig configures the Locale middleware.
+type LocaleConfig struct {
+	// Supported lists the locales of the application, e.g. "en", "pt-BR".
+	Supported []string
+	// Default is the locale used when none of the requested ones is supported,
+	// the first supported locale when empty.
+	Default string
+	// QueryParam and CookieName name the query parameter and the cookie selecting the
+	// locale, "lang" by default. Set them to "-" to ignore them.
+	QueryParam string
+	CookieName string
+}
+
+// Locale returns a middleware resolving the request locale from the query parameter, then
+// the cookie, then the Accept-Language header by q-value, and storing the first one
+// supported under LocaleKey, see c.Locale. A requested locale matches a supported one
+// sharing its language when there is no exact match, e.g. "pt-PT" matches "pt" and "pt-BR".
+func Locale(config LocaleConfig) HandlerFunc {
+	if len(config.Supported) == 0 {
+		panic("no supported locale")
+	}
+	supported := make([]string, len(config.Supported))
+	for i, locale := range config.Supported {
+		supported[i] = normalizeLocale(locale)
+	}
+	if config.Default == "" {
+		config.Default = supported[0]
+	}
+	config.Default = normalizeLocale(config.Default)
+	if config.QueryParam == "" {
+		config.QueryParam = "lang"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "lang"
+	}
+	return func(c *Context) {
+		c.Set(LocaleKey, matchLocale(supported, localeCandidates(c, config.QueryParam, config.CookieName), config.Default))
+	}
+}
+
+// localeCandidates returns the locales requested by the query parameter and the cookie,
+// when their names aren't empty nor "-", then by Accept-Language.
+func localeCandidates(c *Context, queryParam, cookieName string) []string {
+	var candidates []string
+	if queryParam != "" && queryParam != "-" {
+		if locale := c.Query(queryParam); locale != "" {
+			candidates = append(candidates, locale)
+		}
+	}
+	if cookieName != "" && cookieName != "-" {
+		if cookie, err := c.Request.Cookie(cookieName); err == nil && cookie.Value != "" {
+			candidates = append(candidates, cookie.Value)
+		}
+	}
+	for _, locale := range parseAccept(c.Request.Header.Get("Accept-Language")) {
+		if locale != "*" {
+			candidates = append(candidates, locale)
+		}
+	}
+	return candidates
+}
+
+// matchLocale returns the first candidate supported, or its base language, or a supported
+// locale of the same language, and def when none matches.
+func matchLocale(supported, candidates []string, def string) string {
+	for _, candidate := range candidates {
+		candidate = normalizeLocale(candidate)
+		base := baseLanguage(candidate)
+		for _, locale := range supported {
+			if locale == candidate {
+				return locale
+			}
+		}
+		for _, locale := range supported {
+			if locale == base {
+				return locale
+			}
+		}
+		for _, locale := range supported {
+			if baseLanguage(locale) == base {
+				return locale
+			}
+		}
+	}
+	return def
+}
+
+// normalizeLocale turns "pt_br" or "PT-br" into "pt-BR".
+func normalizeLocale(locale string) string {
+	parts := strings.Split(strings.Replace(strings.TrimSpace(locale), "_", "-", -1), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func baseLanguage(locale string) string {
+	if index := strings.IndexByte(locale, '-'); index >= 0 {
+		return locale[:index]
+	}
+	return locale
+}
@@ -0,0 +1,102 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestTranslator() *Translator {
+	t := NewTranslator("en")
+	t.AddMessages("en", map[string]string{"hello": "Hello %s", "bye": "Bye"})
+	t.AddMessages("fr", map[string]string{"hello": "Bonjour %s"})
+	t.AddMessages("pt_br", map[string]string{"hello": "Olá %s"})
+	return t
+}
+
+// TestI18nAcceptLanguage tests that the locale is detected from Accept-Language q-values.
+func TestI18nAcceptLanguage(t *testing.T) {
+	r := New()
+	r.Use(I18n(newTestTranslator(), "lang"))
+	r.GET("/", func(c *Context) {
+		c.String(200, c.Locale()+" "+c.T("hello", "gin")+" "+c.T("bye"))
+	})
+
+	tests := []struct {
+		acceptLanguage, cookie, expected string
+	}{
+		{"de;q=0.9, fr-CA;q=0.8, en;q=0.5", "", "fr Bonjour gin Bye"},
+		{"pt-BR", "", "pt-BR Olá gin Bye"},
+		{"de", "", "en Hello gin Bye"},
+		{"fr", "pt-br", "pt-BR Olá gin Bye"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != test.expected {
+			t.Errorf("Response should be %s, was: %s", test.expected, w.Body.String())
+		}
+	}
+}
+
+// TestI18nFuncMap tests the T template function.
+func TestI18nFuncMap(t *testing.T) {
+	translator := newTestTranslator()
+	r := New()
+	r.Use(I18n(translator, ""))
+	r.SetHTMLTemplate(template.Must(template.New("page").Funcs(translator.FuncMap()).Parse(`{{T .Locale "hello" .Name}}`)))
+	r.GET("/", func(c *Context) {
+		c.HTML(200, "page", H{"Locale": c.Locale(), "Name": "gin"})
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "Bonjour gin" {
+		t.Errorf("Response should be Bonjour gin, was: %s", w.Body.String())
+	}
+}
+
+// TestLocale tests the precedence of the locale sources and the matching of the locales.
+func TestLocale(t *testing.T) {
+	r := New()
+	r.Use(Locale(LocaleConfig{Supported: []string{"en", "pt_BR", "fr"}}))
+	r.GET("/", func(c *Context) {
+		c.String(200, c.Locale())
+	})
+
+	tests := []struct {
+		query, cookie, accept, locale string
+	}{
+		{"", "", "", "en"},
+		{"", "", "de;q=0.9, fr;q=0.5, *", "fr"},
+		{"", "", "pt-PT, en;q=0.8", "pt-BR"},
+		{"", "fr", "pt-BR", "fr"},
+		{"?lang=pt-br", "fr", "en", "pt-BR"},
+		{"?lang=xx", "yy", "zz", "en"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/"+test.query, nil)
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+		}
+		req.Header.Set("Accept-Language", test.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != test.locale {
+			t.Errorf("%+v should resolve to %s, was: %s", test, test.locale, w.Body.String())
+		}
+	}
+}
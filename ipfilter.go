@@ -0,0 +1,56 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"log"
+	"net"
+	"os"
+)
+
+// IPFilterConfig configures the IPFilter middleware. Both lists hold CIDRs or single IPs.
+type IPFilterConfig struct {
+	// Allow, when not empty, lists the only networks allowed.
+	Allow []string
+	// Deny lists the networks rejected, even when they are allowed.
+	Deny []string
+	// Logger receives an entry per rejected request, the standard error by default.
+	Logger *log.Logger
+}
+
+// IPFilter returns a middleware rejecting with 403 Forbidden the requests whose c.ClientIP()
+// isn't allowed, so the forwarded headers are only believed from the trusted proxies (see
+// Engine.SetTrustedProxies). It panics if a list holds an invalid network.
+func IPFilter(config IPFilterConfig) HandlerFunc {
+	allow, err := parseCIDRs(config.Allow)
+	if err != nil {
+		panic(err)
+	}
+	deny, err := parseCIDRs(config.Deny)
+	if err != nil {
+		panic(err)
+	}
+	if config.Logger == nil {
+		config.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return func(c *Context) {
+		clientIP := c.ClientIP()
+		ip := net.ParseIP(clientIP)
+		if ip != nil && !containsIP(deny, ip) && (len(allow) == 0 || containsIP(allow, ip)) {
+			return
+		}
+		config.Logger.Printf("[GIN] ip filter: rejected %s %s %s", clientIP, c.Request.Method, c.Request.URL.Path)
+		c.AbortWithStatus(403)
+	}
+}
+
+func containsIP(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestIPFilter tests the allow and deny lists, and the log of the rejected requests.
+func TestIPFilter(t *testing.T) {
+	logs := new(bytes.Buffer)
+	r := New()
+	r.SetTrustedProxies([]string{"10.0.0.1"})
+	r.Use(IPFilter(IPFilterConfig{
+		Allow:  []string{"192.168.0.0/16", "8.8.8.8"},
+		Deny:   []string{"192.168.1.0/24"},
+		Logger: log.New(logs, "", 0),
+	}))
+	r.GET("/admin", func(c *Context) {})
+
+	tests := []struct {
+		remoteAddr, forwarded string
+		code                  int
+	}{
+		{"192.168.0.10:1234", "", 200},
+		{"8.8.8.8:1234", "", 200},
+		{"192.168.1.10:1234", "", 403},
+		{"1.2.3.4:1234", "", 403},
+		{"1.2.3.4:1234", "8.8.8.8", 403},
+		{"10.0.0.1:1234", "8.8.8.8", 200},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		req.RemoteAddr = test.remoteAddr
+		req.Header.Set("X-Forwarded-For", test.forwarded)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s (%s) should get %d, was: %d", test.remoteAddr, test.forwarded, test.code, w.Code)
+		}
+	}
+	if !strings.Contains(logs.String(), "rejected 192.168.1.10 GET /admin") {
+		t.Errorf("Rejected requests should be logged, was: %s", logs.String())
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const JWTClaimsKey = "jwt_claims"
+
+var (
+	ErrJWTMissing     = errors.New("jwt: missing bearer token")
+	ErrJWTMalformed   = errors.New("jwt: malformed token")
+	ErrJWTAlgorithm   = errors.New("jwt: unexpected signing algorithm")
+	ErrJWTSignature   = errors.New("jwt: invalid signature")
+	ErrJWTExpired     = errors.New("jwt: token is expired")
+	ErrJWTNoExpiry    = errors.New("jwt: token has no expiration time")
+	ErrJWTNotValidYet = errors.New("jwt: token is not valid yet")
+	ErrJWTIssuer      = errors.New("jwt: invalid issuer")
+	ErrJWTAudience    = errors.New("jwt: invalid audience")
+)
+
+type (
+	// JWTClaims are the claims of a verified token.
+	JWTClaims map[string]interface{}
+
+	// JWTConfig configures the JWT middleware. At least one of HMACKey and RSAKey must be set.
+	JWTConfig struct {
+		// HMACKey verifies the HS256 tokens.
+		HMACKey []byte
+		// RSAKey returns the public key verifying the RS256 tokens signed by the key with the
+		// given "kid" header, e.g. JWKS.Key for keys published as a JWK Set.
+		RSAKey func(kid string) (*rsa.PublicKey, error)
+		// Issuer and Audience, if set, must match the "iss" and "aud" claims.
+		Issuer   string
+		Audience string
+		// Leeway tolerates clock skew when checking "exp" and "nbf".
+		Leeway time.Duration
+		// AllowNoExpiry accepts the tokens without "exp" claim, which never expire. By default
+		// they are rejected with ErrJWTNoExpiry.
+		AllowNoExpiry bool
+		// ErrorHandler answers the requests failing authentication, by default with
+		// 401 Unauthorized and a Bearer WWW-Authenticate challenge. It should abort.
+		ErrorHandler func(c *Context, err error)
+
+		now func() time.Time
+	}
+
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+)
+
+// JWT returns a middleware authenticating the requests with the bearer token of their
+// Authorization header. The claims of a valid token are stored under JWTClaimsKey, see c.Claims.
+func JWT(config JWTConfig) HandlerFunc {
+	if config.HMACKey == nil && config.RSAKey == nil {
+		panic("jwt: HMACKey or RSAKey must be set")
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *Context, err error) {
+			c.Writer.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.Fail(401, err)
+		}
+	}
+	if config.now == nil {
+		config.now = time.Now
+	}
+	return func(c *Context) {
+		auth := c.Request.Header.Get("Authorization")
+		if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+			config.ErrorHandler(c, ErrJWTMissing)
+			return
+		}
+		claims, err := config.verify(strings.TrimSpace(auth[7:]))
+		if err != nil {
+			config.ErrorHandler(c, err)
+			return
+		}
+		c.Set(JWTClaimsKey, claims)
+	}
+}
+
+// Claims returns the claims of the token verified by the JWT middleware, nil without it.
+func (c *Context) Claims() JWTClaims {
+	if claims, err := c.Get(JWTClaimsKey); err == nil {
+		if jwtClaims, ok := claims.(JWTClaims); ok {
+			return jwtClaims
+		}
+	}
+	return nil
+}
+
+func (config *JWTConfig) verify(token string) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+
+	switch {
+	case header.Alg == "HS256" && config.HMACKey != nil:
+		mac := hmac.New(sha256.New, config.HMACKey)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, ErrJWTSignature
+		}
+	case header.Alg == "RS256" && config.RSAKey != nil:
+		key, err := config.RSAKey(header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return nil, ErrJWTSignature
+		}
+	default:
+		return nil, ErrJWTAlgorithm
+	}
+
+	var claims JWTClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := config.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok && !config.AllowNoExpiry {
+		return nil, ErrJWTNoExpiry
+	}
+	if ok && now.After(time.Unix(int64(exp), 0).Add(config.Leeway)) {
+		return nil, ErrJWTExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(config.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrJWTNotValidYet
+	}
+	if config.Issuer != "" && claims["iss"] != config.Issuer {
+		return nil, ErrJWTIssuer
+	}
+	if config.Audience != "" && !claims.hasAudience(config.Audience) {
+		return nil, ErrJWTAudience
+	}
+	return claims, nil
+}
+
+// hasAudience checks the "aud" claim, a single string or an array of strings.
+func (claims JWTClaims) hasAudience(audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrJWTMalformed
+	}
+	return nil
+}
+
+// JWKS fetches the RSA public keys of a JWK Set document, e.g. an OpenID provider's jwks_uri.
+// The keys are refreshed every refresh interval, and on demand (at most once a minute) when
+// a token refers to an unknown key, so rotated keys are picked up. A single fetch runs at a
+// time, outside the lock: the known keys are still served while the set is refreshed, only
+// the tokens referring to an unknown key wait for it.
+type JWKS struct {
+	url       string
+	refresh   time.Duration
+	client    *http.Client
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	fetchErr  error
+	fetching  chan struct{}
+}
+
+// NewJWKS returns the key set published at url, refreshed every hour when refresh is zero.
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	return &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key returns the key called kid, to be used as JWTConfig.RSAKey.
+func (j *JWKS) Key(kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	age := time.Since(j.fetchedAt)
+	key, ok := j.keys[kid]
+	if j.keys == nil || age > j.refresh || (!ok && age > time.Minute) {
+		done := j.startFetch()
+		if !ok {
+			j.mu.Unlock()
+			<-done
+			j.mu.Lock()
+			key, ok = j.keys[kid]
+		}
+	}
+	keys, err := j.keys, j.fetchErr
+	j.mu.Unlock()
+	if !ok {
+		if keys == nil && err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("jwt: unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// startFetch fetches the key set in the background unless a fetch is already running, and
+// returns a channel closed once it is done. j.mu must be held.
+func (j *JWKS) startFetch() chan struct{} {
+	if j.fetching != nil {
+		return j.fetching
+	}
+	done := make(chan struct{})
+	j.fetching = done
+	j.fetchedAt = time.Now()
+	go func() {
+		keys, err := j.fetch()
+		j.mu.Lock()
+		if err == nil {
+			j.keys = keys
+		}
+		j.fetchErr = err
+		j.fetching = nil
+		j.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (j *JWKS) fetch() (map[string]*rsa.PublicKey, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("jwt: fetching %s: %s", j.url, resp.Status)
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
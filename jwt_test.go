@@ -0,0 +1,174 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func signJWT(header, claims map[string]interface{}, sign func([]byte) []byte) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func performJWT(r http.Handler, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestJWTHS256 tests the HS256 signature and the registered claims checks.
+func TestJWTHS256(t *testing.T) {
+	key := []byte("secret")
+	hs256 := func(data []byte) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+		return mac.Sum(nil)
+	}
+	now := time.Unix(1500000000, 0)
+	r := New()
+	r.Use(JWT(JWTConfig{HMACKey: key, Issuer: "auth", Audience: "api", now: func() time.Time { return now }}))
+	r.GET("/", func(c *Context) {
+		c.String(200, "%v", c.Claims()["sub"])
+	})
+
+	header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	valid := map[string]interface{}{"sub": "42", "iss": "auth", "aud": []string{"web", "api"}, "exp": now.Unix() + 60}
+
+	if w := performJWT(r, signJWT(header, valid, hs256)); w.Code != 200 || w.Body.String() != "42" {
+		t.Errorf("Valid token should be accepted, was: %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+	}{
+		{"missing", "", `Bearer error="invalid_token"`},
+		{"expired", signJWT(header, map[string]interface{}{"iss": "auth", "aud": "api", "exp": now.Unix() - 1}, hs256), ""},
+		{"not yet valid", signJWT(header, map[string]interface{}{"iss": "auth", "aud": "api", "exp": now.Unix() + 120, "nbf": now.Unix() + 60}, hs256), ""},
+		{"no expiry", signJWT(header, map[string]interface{}{"iss": "auth", "aud": "api"}, hs256), ""},
+		{"issuer", signJWT(header, map[string]interface{}{"iss": "other", "aud": "api", "exp": now.Unix() + 60}, hs256), ""},
+		{"audience", signJWT(header, map[string]interface{}{"iss": "auth", "aud": "web", "exp": now.Unix() + 60}, hs256), ""},
+		{"signature", signJWT(header, valid, func([]byte) []byte { return []byte("forged") }), ""},
+		{"none", signJWT(map[string]interface{}{"alg": "none"}, valid, func([]byte) []byte { return nil }), ""},
+		{"malformed", "a.b", ""},
+	}
+	for _, test := range tests {
+		w := performJWT(r, test.token)
+		if w.Code != 401 {
+			t.Errorf("%s token should be rejected, was: %d", test.name, w.Code)
+		}
+		if test.header != "" && w.HeaderMap.Get("WWW-Authenticate") != test.header {
+			t.Errorf("WWW-Authenticate should be %s, was: %s", test.header, w.HeaderMap.Get("WWW-Authenticate"))
+		}
+	}
+
+	r = New()
+	r.Use(JWT(JWTConfig{HMACKey: key, AllowNoExpiry: true}))
+	r.GET("/", func(c *Context) {})
+	if w := performJWT(r, signJWT(header, map[string]interface{}{"sub": "42"}, hs256)); w.Code != 200 {
+		t.Errorf("Token without expiry should be accepted with AllowNoExpiry, was: %d", w.Code)
+	}
+}
+
+// TestJWTRS256JWKS tests RS256 tokens verified with keys fetched from a JWK Set.
+func TestJWTRS256JWKS(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `{"keys":[{"kty":"RSA","kid":"k1","n":"%s","e":"%s"}]}`,
+			base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()))
+	}))
+	defer server.Close()
+
+	rs256 := func(data []byte) []byte {
+		digest := sha256.Sum256(data)
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, digest[:])
+		return signature
+	}
+	var errs []error
+	r := New()
+	r.Use(JWT(JWTConfig{
+		RSAKey: NewJWKS(server.URL, 0).Key,
+		ErrorHandler: func(c *Context, err error) {
+			errs = append(errs, err)
+			c.AbortWithStatus(403)
+		},
+	}))
+	r.GET("/", func(c *Context) {
+		c.String(200, "%v", c.Claims()["sub"])
+	})
+
+	claims := map[string]interface{}{"sub": "7", "exp": time.Now().Add(time.Hour).Unix()}
+	if w := performJWT(r, signJWT(map[string]interface{}{"alg": "RS256", "kid": "k1"}, claims, rs256)); w.Code != 200 || w.Body.String() != "7" {
+		t.Errorf("Valid token should be accepted, was: %d %s", w.Code, w.Body.String())
+	}
+	if w := performJWT(r, signJWT(map[string]interface{}{"alg": "RS256", "kid": "k2"}, claims, rs256)); w.Code != 403 || len(errs) != 1 {
+		t.Errorf("Token signed by an unknown key should go to the error handler, was: %d %v", w.Code, errs)
+	}
+}
+
+// TestJWKSRefresh tests that the known keys are served while the key set is refreshed.
+func TestJWKSRefresh(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			started <- struct{}{}
+			<-release
+		}
+		fmt.Fprint(w, `{"keys":[{"kty":"RSA","kid":"k1","n":"AQAB","e":"AQAB"}]}`)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	jwks := NewJWKS(server.URL, time.Millisecond)
+	if _, err := jwks.Key("k1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	done := make(chan error)
+	go func() {
+		_, err := jwks.Key("k1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Known key should be served during the refresh, was: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Known key should not wait for the refresh")
+	}
+	<-started
+	jwks.Key("k1")
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("A single refresh should run at a time, was: %d fetches", n)
+	}
+}
@@ -5,7 +5,12 @@
 package gin
 
 import (
-	"log"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/url"
+	"sync"
 	"third/go-colorable"
 	"time"
 )
@@ -68,43 +73,170 @@ func ErrorLoggerT(typ uint32) HandlerFunc {
 		errs := c.Errors.ByType(typ)
 		if len(errs) > 0 {
 			// -1 status code = do not change current one
-			c.JSON(-1, c.Errors)
+			c.JSON(-1, []*Error(c.Errors))
 		}
 	}
 }
 
+type (
+	// LogEntry describes a request logged by the Logger middlewares.
+	LogEntry struct {
+		// Time is when the request started.
+		Time      time.Time
+		Latency   time.Duration
+		Method    string
+		Route     string
+		URL       *url.URL
+		Status    int
+		Bytes     int
+		ClientIP  string
+		RequestID string
+		Errors    errorMsgs
+		// Keys are the keys of the Context, valid during the call to the formatter only.
+		Keys map[string]interface{}
+	}
+
+	// LoggerConfig configures LoggerWithConfig.
+	LoggerConfig struct {
+		// Output defaults to the standard output.
+		Output io.Writer
+		// SkipPaths lists the URL paths not logged, e.g. the health check ones.
+		SkipPaths []string
+		// Skip, if set, reports whether a request is not logged.
+		Skip func(c *Context) bool
+		// Formatter returns the line of an entry, the colored text one of Logger by default.
+		Formatter func(entry LogEntry) string
+		// SampleRate is the fraction of the successful requests logged, e.g. 0.01 for 1%,
+		// all of them when zero. Requests with a status of 400 or more, with errors, or
+		// slower than SlowThreshold are always logged.
+		SampleRate    float64
+		SlowThreshold time.Duration
+	}
+)
+
+// Logger returns a middleware logging the requests to the standard output in colored text.
 func Logger() HandlerFunc {
-	stdlogger := log.New(colorable.NewColorableStdout(), "", 0)
-	//errlogger := log.New(os.Stderr, "", 0)
+	return LoggerWithConfig(LoggerConfig{})
+}
+
+// LoggerJSON returns a middleware writing one JSON line per request to out, the standard output
+// when nil, see JSONLogFormatter.
+func LoggerJSON(out io.Writer, keys ...string) HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{Output: out, Formatter: JSONLogFormatter(keys...)})
+}
+
+// LoggerWithConfig returns a middleware logging the requests that aren't skipped with the
+// formatter of config.
+func LoggerWithConfig(config LoggerConfig) HandlerFunc {
+	if config.Output == nil {
+		config.Output = colorable.NewColorableStdout()
+	}
+	if config.Formatter == nil {
+		config.Formatter = defaultLogFormatter
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+	var mu sync.Mutex
 
 	return func(c *Context) {
 		// Start timer
 		start := time.Now()
+		path := c.Request.URL.Path
 
 		// Process request
 		c.Next()
 
-		// Stop timer
-		end := time.Now()
-		latency := end.Sub(start)
-
-		clientIP := c.ClientIP()
-		method := c.Request.Method
-		statusCode := c.Writer.Status()
-		statusColor := colorForStatus(statusCode)
-		methodColor := colorForMethod(method)
-
-		stdlogger.Printf("[GIN] %v |%s %3d %s| %12v | %s |%s  %s %-7s %s %s %s\n%s",
-			end.Format("2006/01/02 - 15:04:05"),
-			statusColor, statusCode, reset,
-			latency,
-			clientIP,
-			methodColor, method, reset,
-			c.Request.URL.Path,
-			c.Request.URL.String(),
-			c.Request.URL.Opaque,
-			c.Errors.String(),
-		)
+		if skip[path] || (config.Skip != nil && config.Skip(c)) {
+			return
+		}
+		if config.SampleRate > 0 && config.SampleRate < 1 && c.Writer.Status() < 400 && len(c.Errors) == 0 &&
+			(config.SlowThreshold <= 0 || time.Since(start) < config.SlowThreshold) && rand.Float64() >= config.SampleRate {
+			return
+		}
+		entry := LogEntry{
+			Time:      start,
+			Latency:   time.Since(start),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			URL:       c.Request.URL,
+			Status:    c.Writer.Status(),
+			ClientIP:  c.ClientIP(),
+			RequestID: c.RequestID(),
+			Errors:    c.Errors,
+		}
+		if c.Writer.Written() {
+			entry.Bytes = c.Writer.Size()
+		}
+		c.keysMutex.RLock()
+		entry.Keys = c.Keys
+		line := config.Formatter(entry)
+		c.keysMutex.RUnlock()
+
+		mu.Lock()
+		defer mu.Unlock()
+		io.WriteString(config.Output, line)
+	}
+}
+
+func defaultLogFormatter(entry LogEntry) string {
+	clientIP := entry.ClientIP
+	if entry.RequestID != "" {
+		clientIP += " | " + entry.RequestID
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %12v | %s |%s  %s %-7s %s %s %s\n%s",
+		entry.Time.Add(entry.Latency).Format("2006/01/02 - 15:04:05"),
+		colorForStatus(entry.Status), entry.Status, reset,
+		entry.Latency,
+		clientIP,
+		colorForMethod(entry.Method), entry.Method, reset,
+		entry.URL.Path,
+		entry.URL.String(),
+		entry.URL.Opaque,
+		entry.Errors.String(),
+	)
+}
+
+// JSONLogFormatter returns a LoggerConfig.Formatter writing the entries as JSON lines:
+//
+//	{"time":"2015-05-20T10:26:00.5+02:00","method":"GET","route":"/users/:id","path":"/users/1",
+//	 "status":200,"latency":0.000107,"bytes":42,"client_ip":"127.0.0.1","request_id":"..."}
+//
+// keys lists the Context keys added to the line, e.g. a user ID set by an auth middleware;
+// the keys not set are left out.
+func JSONLogFormatter(keys ...string) func(entry LogEntry) string {
+	return func(entry LogEntry) string {
+		line := map[string]interface{}{
+			"time":      entry.Time.Format(time.RFC3339Nano),
+			"method":    entry.Method,
+			"route":     entry.Route,
+			"path":      entry.URL.Path,
+			"status":    entry.Status,
+			"latency":   entry.Latency.Seconds(),
+			"bytes":     entry.Bytes,
+			"client_ip": entry.ClientIP,
+		}
+		if entry.RequestID != "" {
+			line["request_id"] = entry.RequestID
+		}
+		if len(entry.Errors) > 0 {
+			errs := make([]string, len(entry.Errors))
+			for i, msg := range entry.Errors {
+				errs[i] = msg.Error()
+			}
+			line["errors"] = errs
+		}
+		for _, key := range keys {
+			if value, ok := entry.Keys[key]; ok {
+				line[key] = value
+			}
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			data, _ = json.Marshal(map[string]interface{}{"time": line["time"], "error": err.Error()})
+		}
+		return string(data) + "\n"
 	}
 }
 
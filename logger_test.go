@@ -0,0 +1,112 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoggerJSON tests the fields of the JSON access log.
+func TestLoggerJSON(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(RequestID(), LoggerJSON(output, "user_id", "missing"))
+	r.GET("/users/:id", func(c *Context) {
+		c.Set("user_id", 42)
+		c.String(200, "hello")
+	})
+	PerformRequest(r, "GET", "/users/1")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &line); err != nil {
+		t.Fatalf("Log line should be JSON, was: %s", output.String())
+	}
+	expected := map[string]interface{}{
+		"method":  "GET",
+		"route":   "/users/:id",
+		"path":    "/users/1",
+		"status":  float64(200),
+		"bytes":   float64(5),
+		"user_id": float64(42),
+	}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("%s should be %v, was: %v", key, value, line[key])
+		}
+	}
+	for _, key := range []string{"time", "latency", "client_ip", "request_id"} {
+		if _, ok := line[key]; !ok {
+			t.Errorf("%s should be logged, was: %s", key, output.String())
+		}
+	}
+	if _, ok := line["missing"]; ok {
+		t.Errorf("Keys not set should be left out")
+	}
+}
+
+// TestLoggerWithConfig tests the skipped requests and the custom formatter.
+func TestLoggerWithConfig(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{
+		Output:    output,
+		SkipPaths: []string{"/healthz"},
+		Skip:      func(c *Context) bool { return c.Writer.Status() == 304 },
+		Formatter: func(entry LogEntry) string {
+			return entry.Method + " " + entry.Route + " " + entry.URL.Path + "\n"
+		},
+	}))
+	r.GET("/healthz", func(c *Context) {})
+	r.GET("/cached", func(c *Context) { c.AbortWithStatus(304) })
+	r.GET("/users/:id", func(c *Context) {})
+
+	PerformRequest(r, "GET", "/healthz")
+	PerformRequest(r, "GET", "/cached")
+	PerformRequest(r, "GET", "/users/1")
+	if output.String() != "GET /users/:id /users/1\n" {
+		t.Errorf("Only /users/1 should be logged, was: %q", output.String())
+	}
+}
+
+// TestLogger tests the default text format.
+func TestLogger(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{Output: output}))
+	r.GET("/users/:id", func(c *Context) {})
+	PerformRequest(r, "GET", "/users/1?page=2")
+
+	if line := output.String(); !strings.HasPrefix(line, "[GIN] ") || !strings.Contains(line, "/users/1?page=2") || strings.Count(line, "\n") != 1 {
+		t.Errorf("Request should be logged on one line, was: %q", line)
+	}
+}
+
+// TestLoggerSampling tests that errors and slow requests are logged whatever the sample rate.
+func TestLoggerSampling(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{
+		Output:        output,
+		Formatter:     func(entry LogEntry) string { return entry.URL.Path + "\n" },
+		SampleRate:    1e-12,
+		SlowThreshold: 20 * time.Millisecond,
+	}))
+	r.GET("/ok", func(c *Context) {})
+	r.GET("/fail", func(c *Context) { c.AbortWithStatus(500) })
+	r.GET("/slow", func(c *Context) { time.Sleep(30 * time.Millisecond) })
+
+	for i := 0; i < 10; i++ {
+		PerformRequest(r, "GET", "/ok")
+	}
+	PerformRequest(r, "GET", "/fail")
+	PerformRequest(r, "GET", "/slow")
+	if output.String() != "/fail\n/slow\n" {
+		t.Errorf("Only the failed and slow requests should be logged, was: %q", output.String())
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// maintenance is the maintenance mode of the process, shared like the graceful exit state
+// so the admin server can switch it.
+var maintenance int32
+
+// SetMaintenance switches the maintenance mode on or off. The admin server does it with
+// POST /admin/maintenance?on=true.
+func SetMaintenance(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&maintenance, v)
+}
+
+// IsMaintenance reports whether the maintenance mode is on.
+func IsMaintenance() bool {
+	return atomic.LoadInt32(&maintenance) == 1
+}
+
+// MaintenanceConfig configures the Maintenance middleware.
+type MaintenanceConfig struct {
+	// Body is sent with the 503 responses, as text unless ContentType says otherwise.
+	Body        string
+	ContentType string
+	// RetryAfter is sent in the Retry-After header, not sent when zero.
+	RetryAfter time.Duration
+	// AllowPaths lists the URL paths still served, e.g. the health checks.
+	AllowPaths []string
+	// AllowIPs lists the networks (CIDRs or single IPs) still served, matched against
+	// c.ClientIP(), e.g. the office to test the release.
+	AllowIPs []string
+}
+
+// Maintenance returns a middleware answering 503 Service Unavailable to all the requests while
+// the maintenance mode is on, except the ones for the allowed paths or from the allowed IPs.
+// It panics if AllowIPs holds an invalid network.
+func Maintenance(config MaintenanceConfig) HandlerFunc {
+	allowIPs, err := parseCIDRs(config.AllowIPs)
+	if err != nil {
+		panic(err)
+	}
+	allowPaths := make(map[string]bool, len(config.AllowPaths))
+	for _, path := range config.AllowPaths {
+		allowPaths[path] = true
+	}
+	if config.Body == "" {
+		config.Body = "service under maintenance"
+	}
+	if config.ContentType == "" {
+		config.ContentType = MIMEPlain + "; charset=utf-8"
+	}
+	return func(c *Context) {
+		if !IsMaintenance() || allowPaths[c.Request.URL.Path] {
+			return
+		}
+		if ip := net.ParseIP(c.ClientIP()); ip != nil && containsIP(allowIPs, ip) {
+			return
+		}
+		if config.RetryAfter > 0 {
+			c.Writer.Header().Set("Retry-After", strconv.FormatInt(int64((config.RetryAfter+time.Second-1)/time.Second), 10))
+		}
+		c.Abort()
+		c.Data(503, config.ContentType, []byte(config.Body))
+	}
+}
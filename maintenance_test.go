@@ -0,0 +1,56 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMaintenance tests the maintenance mode, its allowlists and its admin endpoint.
+func TestMaintenance(t *testing.T) {
+	log.SetOutput(bytes.NewBuffer(nil))
+	defer log.SetOutput(os.Stderr)
+	defer SetMaintenance(false)
+
+	admin := New()
+	admin.POST("/admin/maintenance", admin.maintenanceHandler)
+	r := New()
+	r.Use(Maintenance(MaintenanceConfig{
+		RetryAfter: time.Minute,
+		AllowPaths: []string{"/readyz"},
+		AllowIPs:   []string{"10.1.0.0/16"},
+	}))
+	r.GET("/", func(c *Context) { c.String(200, "home") })
+	r.GET("/readyz", func(c *Context) {})
+
+	if w := PerformRequest(r, "GET", "/"); w.Code != 200 {
+		t.Errorf("Requests should be served out of maintenance, was: %d", w.Code)
+	}
+	PerformRequest(admin, "POST", "/admin/maintenance?on=true")
+	w := PerformRequest(r, "GET", "/")
+	if w.Code != 503 || w.Body.String() != "service under maintenance" || w.HeaderMap.Get("Retry-After") != "60" {
+		t.Errorf("Requests should be rejected in maintenance, was: %d %q", w.Code, w.Body.String())
+	}
+	if w := PerformRequest(r, "GET", "/readyz"); w.Code != 200 {
+		t.Errorf("Allowed paths should be served in maintenance, was: %d", w.Code)
+	}
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("Allowed IPs should be served in maintenance, was: %d", w.Code)
+	}
+	PerformRequest(admin, "POST", "/admin/maintenance?on=false")
+	if w := PerformRequest(r, "GET", "/"); w.Code != 200 {
+		t.Errorf("Requests should be served after maintenance, was: %d", w.Code)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Metrics collects the request metrics of the engine, exported in the Prometheus text
+	// format by its Handler:
+	//
+	//	<namespace>_http_requests_total{method,route,status}
+	//	<namespace>_http_request_duration_seconds{method,route,status}  (histogram)
+	//	<namespace>_http_response_size_bytes{method,route,status}       (histogram)
+	//	<namespace>_http_requests_in_flight
+	//
+	// route is the route template, e.g. "/users/:id", and empty for unmatched requests, and
+	// the methods other than the standard ones are counted as "OTHER", so the labels stay
+	// bounded whatever the requests.
+	Metrics struct {
+		namespace       string
+		durationBuckets []float64
+		sizeBuckets     []float64
+		inFlight        int64
+
+		mu     sync.Mutex
+		series map[metricsLabels]*metricsSeries
+	}
+
+	metricsLabels struct {
+		method, route string
+		status        int
+	}
+
+	metricsSeries struct {
+		count    uint64
+		duration histogram
+		size     histogram
+	}
+
+	histogram struct {
+		counts []uint64
+		sum    float64
+	}
+)
+
+var (
+	// DefaultDurationBuckets are the buckets of the request duration histogram, in seconds.
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the buckets of the response size histogram, in bytes.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// NewMetrics returns the Metrics of the given namespace, "gin" when empty, using
+// DefaultDurationBuckets and DefaultSizeBuckets. Mount its middleware and handler with:
+//
+//	metrics := gin.NewMetrics("api")
+//	r.Use(metrics.Middleware())
+//	r.GET("/metrics", metrics.Handler())
+func NewMetrics(namespace string) *Metrics {
+	if namespace == "" {
+		namespace = "gin"
+	}
+	return &Metrics{
+		namespace:       namespace,
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		series:          make(map[metricsLabels]*metricsSeries),
+	}
+}
+
+// Middleware returns the middleware measuring the requests.
+func (m *Metrics) Middleware() HandlerFunc {
+	return func(c *Context) {
+		start := time.Now()
+		atomic.AddInt64(&m.inFlight, 1)
+		defer atomic.AddInt64(&m.inFlight, -1)
+
+		c.Next()
+
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		labels := metricsLabels{metricsMethod(c.Request.Method), c.FullPath(), c.Writer.Status()}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		s, ok := m.series[labels]
+		if !ok {
+			s = &metricsSeries{
+				duration: histogram{counts: make([]uint64, len(m.durationBuckets))},
+				size:     histogram{counts: make([]uint64, len(m.sizeBuckets))},
+			}
+			m.series[labels] = s
+		}
+		s.count++
+		s.duration.observe(m.durationBuckets, time.Since(start).Seconds())
+		s.size.observe(m.sizeBuckets, float64(size))
+	}
+}
+
+// metricsMethod returns the method label of a request, "OTHER" for non-standard methods.
+func metricsMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE":
+		return method
+	}
+	return "OTHER"
+}
+
+// Handler returns the handler exporting the metrics in the Prometheus text format.
+func (m *Metrics) Handler() HandlerFunc {
+	return func(c *Context) {
+		c.Data(200, "text/plain; version=0.0.4; charset=utf-8", m.export())
+	}
+}
+
+func (m *Metrics) export() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	labels := make([]metricsLabels, 0, len(m.series))
+	for l := range m.series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	buf := new(bytes.Buffer)
+	name := m.namespace + "_http_requests_total"
+	fmt.Fprintf(buf, "# HELP %s Number of HTTP requests.\n# TYPE %s counter\n", name, name)
+	for _, l := range labels {
+		fmt.Fprintf(buf, "%s{%s} %d\n", name, l, m.series[l].count)
+	}
+	name = m.namespace + "_http_request_duration_seconds"
+	fmt.Fprintf(buf, "# HELP %s Duration of HTTP requests.\n# TYPE %s histogram\n", name, name)
+	for _, l := range labels {
+		m.series[l].duration.write(buf, name, l, m.durationBuckets, m.series[l].count)
+	}
+	name = m.namespace + "_http_response_size_bytes"
+	fmt.Fprintf(buf, "# HELP %s Size of HTTP responses.\n# TYPE %s histogram\n", name, name)
+	for _, l := range labels {
+		m.series[l].size.write(buf, name, l, m.sizeBuckets, m.series[l].count)
+	}
+	name = m.namespace + "_http_requests_in_flight"
+	fmt.Fprintf(buf, "# HELP %s Number of HTTP requests being served.\n# TYPE %s gauge\n", name, name)
+	fmt.Fprintf(buf, "%s %d\n", name, atomic.LoadInt64(&m.inFlight))
+	return buf.Bytes()
+}
+
+func (l metricsLabels) String() string {
+	return fmt.Sprintf(`method="%s",route="%s",status="%d"`, labelEscaper.Replace(l.method), labelEscaper.Replace(l.route), l.status)
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	for i, bound := range buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+}
+
+func (h *histogram) write(buf *bytes.Buffer, name string, l metricsLabels, buckets []float64, count uint64) {
+	for i, bound := range buckets {
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, l, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, l, count)
+	fmt.Fprintf(buf, "%s_sum{%s} %s\n", name, l, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(buf, "%s_count{%s} %d\n", name, l, count)
+}
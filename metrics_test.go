@@ -0,0 +1,48 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMetrics tests that requests are counted by route template.
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics("test")
+	r := New()
+	r.Use(metrics.Middleware())
+	r.GET("/users/:id", func(c *Context) {
+		c.String(200, "user")
+	})
+	r.GET("/metrics", metrics.Handler())
+
+	PerformRequest(r, "GET", "/users/1")
+	PerformRequest(r, "GET", "/users/2")
+	PerformRequest(r, "GET", "/missing")
+	PerformRequest(r, "FOO1", "/missing")
+	PerformRequest(r, "FOO2", "/missing")
+	w := PerformRequest(r, "GET", "/metrics")
+
+	body := w.Body.String()
+	expected := []string{
+		`test_http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`test_http_requests_total{method="GET",route="",status="404"} 1`,
+		`test_http_requests_total{method="OTHER",route="",status="404"} 2`,
+		`test_http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`,
+		`test_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="200",le="100"} 2`,
+		`test_http_response_size_bytes_sum{method="GET",route="/users/:id",status="200"} 8`,
+		"test_http_requests_in_flight 1",
+		"# TYPE test_http_request_duration_seconds histogram",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Metrics should contain %q, was:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "FOO") {
+		t.Errorf("Non-standard methods should not be labels, was:\n%s", body)
+	}
+}
@@ -7,6 +7,7 @@ package gin
 import (
 	"fmt"
 	"os"
+	"third/gin/codec/json"
 )
 
 const GIN_MODE = "GIN_MODE"
@@ -48,6 +49,12 @@ func SetMode(value string) {
 	mode_name = value
 }
 
+// SetJSON sets the JSON implementation of the renders and of the binding. Like the mode it
+// is process wide, shared by every Engine, and should be set once at startup.
+func SetJSON(api json.Core) {
+	json.API = api
+}
+
 func Mode() string {
 	return mode_name
 }
@@ -0,0 +1,21 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build msgpack
+// +build msgpack
+
+package gin
+
+import "third/gin/render"
+
+func init() {
+	msgpackRender = render.MsgPack
+}
+
+// Serializes the given struct as MessagePack into the response body.
+// It also sets the Content-Type as "application/x-msgpack".
+// It is only available when building with the msgpack tag, which needs third/msgpack.
+func (c *Context) MsgPack(code int, obj interface{}) {
+	c.Render(code, render.MsgPack, obj)
+}
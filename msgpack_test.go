@@ -0,0 +1,73 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build msgpack
+// +build msgpack
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"third/msgpack"
+)
+
+// TestMsgPack tests binding a MessagePack body, rendering MessagePack and negotiating it.
+func TestMsgPack(t *testing.T) {
+	type user struct {
+		Name string `msgpack:"name" binding:"required"`
+		Age  int    `msgpack:"age"`
+	}
+
+	r := New()
+	r.POST("/user", func(c *Context) {
+		var obj user
+		if c.Bind(&obj) {
+			c.MsgPack(200, obj)
+		}
+	})
+	r.GET("/negotiate", func(c *Context) {
+		c.Negotiate(200, Negotiate{Offered: []string{MIMEJSON, MIMEMSGPACK}, Data: user{Name: "gin", Age: 3}})
+	})
+
+	var body bytes.Buffer
+	if err := msgpack.NewEncoder(&body).Encode(user{Name: "gin", Age: 3}); err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/user", &body)
+	req.Header.Set("Content-Type", MIMEMSGPACK)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.HeaderMap.Get("Content-Type") != "application/x-msgpack" {
+		t.Fatalf("MessagePack should be bound and rendered, was: %d %v", w.Code, w.HeaderMap)
+	}
+	var obj user
+	if err := msgpack.NewDecoder(w.Body).Decode(&obj); err != nil || obj.Name != "gin" || obj.Age != 3 {
+		t.Errorf("Response should be the MessagePack of the user, was: %+v %v", obj, err)
+	}
+
+	req, _ = http.NewRequest("GET", "/negotiate", nil)
+	req.Header.Set("Accept", MIMEMSGPACK)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.HeaderMap.Get("Content-Type") != "application/x-msgpack" {
+		t.Errorf("MessagePack should be negotiated, was: %d %v", w.Code, w.HeaderMap)
+	}
+}
+
+// TestMsgPackRenderError tests that a MessagePack encoding error leaves the response to the
+// error handling.
+func TestMsgPackRenderError(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.MsgPack(200, make(chan int))
+	})
+
+	w := PerformRequest(r, "GET", "/")
+	if w.Code != 500 || w.HeaderMap.Get("Content-Type") != "" {
+		t.Errorf("Failing MessagePack should be answered with 500, was: %d %v", w.Code, w.HeaderMap)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"third/gin/codec/json"
+	"time"
+)
+
+// Option configures an Engine created by NewWithOptions.
+type Option func(engine *Engine)
+
+// NewWithOptions returns a new blank Engine like New, configured by opts in order:
+//
+//	r := gin.NewWithOptions(
+//		gin.WithTrustedProxies("10.0.0.0/8"),
+//		gin.WithTimeouts(5*time.Second, 0, 10*time.Second, time.Minute),
+//	)
+func NewWithOptions(opts ...Option) *Engine {
+	engine := New()
+	for _, opt := range opts {
+		opt(engine)
+	}
+	return engine
+}
+
+// With404Body sets the body of the default 404 responses.
+func With404Body(body []byte) Option {
+	return func(engine *Engine) {
+		engine.Default404Body = body
+	}
+}
+
+// With405Body sets the body of the default 405 responses.
+func With405Body(body []byte) Option {
+	return func(engine *Engine) {
+		engine.Default405Body = body
+	}
+}
+
+// WithTrustedProxies sets the proxies trusted by ClientIP, see Engine.SetTrustedProxies.
+// It panics if a proxy is not a valid IP or CIDR.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(engine *Engine) {
+		if err := engine.SetTrustedProxies(proxies); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// WithRemoteIPHeaders sets the headers giving the client IP behind the trusted proxies.
+func WithRemoteIPHeaders(headers ...string) Option {
+	return func(engine *Engine) {
+		engine.RemoteIPHeaders = headers
+	}
+}
+
+// WithoutContextReuse stops recycling the Contexts of the finished requests, for the
+// applications whose handlers keep their Context after returning instead of calling c.Copy.
+func WithoutContextReuse() Option {
+	return func(engine *Engine) {
+		engine.noContextReuse = true
+	}
+}
+
+// WithJSON sets the JSON implementation of the renders and of the binding, see SetJSON.
+// Unlike the other options it is not specific to the Engine created: the JSON implementation
+// is process wide, so it also applies to every other Engine of the process.
+func WithJSON(api json.Core) Option {
+	return func(engine *Engine) {
+		SetJSON(api)
+	}
+}
+
+// WithFuncMap sets the template functions of the HTML templates loaded afterwards.
+func WithFuncMap(funcMap template.FuncMap) Option {
+	return func(engine *Engine) {
+		engine.SetFuncMap(funcMap)
+	}
+}
+
+// WithDelims sets the action delimiters of the HTML templates loaded afterwards.
+func WithDelims(left, right string) Option {
+	return func(engine *Engine) {
+		engine.Delims(left, right)
+	}
+}
+
+// WithHTMLTemplate sets the HTML templates rendered by c.HTML, see Engine.SetHTMLTemplate.
+func WithHTMLTemplate(templ *template.Template) Option {
+	return func(engine *Engine) {
+		engine.SetHTMLTemplate(templ)
+	}
+}
+
+// WithTimeouts sets the timeouts of the servers started by the Run methods; the zero ones
+// keep the default.
+func WithTimeouts(read, readHeader, write, idle time.Duration) Option {
+	return func(engine *Engine) {
+		if read > 0 {
+			engine.ReadTimeout = read
+		}
+		if readHeader > 0 {
+			engine.ReadHeaderTimeout = readHeader
+		}
+		if write > 0 {
+			engine.WriteTimeout = write
+		}
+		if idle > 0 {
+			engine.IdleTimeout = idle
+		}
+	}
+}
+
+// WithMaxHeaderBytes sets the request header size limit of the servers started by the Run methods.
+func WithMaxHeaderBytes(n int) Option {
+	return func(engine *Engine) {
+		engine.MaxHeaderBytes = n
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"third/gin/codec/json"
+	"time"
+)
+
+// TestNewWithOptions tests that the options configure the engine.
+func TestNewWithOptions(t *testing.T) {
+	r := NewWithOptions(
+		With404Body([]byte("nothing here")),
+		WithTrustedProxies("10.0.0.1"),
+		WithRemoteIPHeaders("X-Real-IP"),
+		WithoutContextReuse(),
+		WithDelims("[[", "]]"),
+		WithHTMLTemplate(template.Must(template.New("page").Parse(`{{.}}`))),
+		WithTimeouts(time.Second, 0, 2*time.Second, 0),
+		WithMaxHeaderBytes(4096),
+	)
+	var contexts []*Context
+	r.GET("/ip", func(c *Context) {
+		contexts = append(contexts, c)
+		c.String(200, c.ClientIP())
+	})
+
+	if w := PerformRequest(r, "GET", "/missing"); w.Body.String() != "nothing here" {
+		t.Errorf("404 body should be set, was: %q", w.Body.String())
+	}
+	req, _ := http.NewRequest("GET", "/ip", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Real-IP", "8.8.8.8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "8.8.8.8" {
+		t.Errorf("Trusted proxies and headers should be set, was: %q", w.Body.String())
+	}
+	PerformRequest(r, "GET", "/ip")
+	if len(contexts) != 2 || contexts[0] == contexts[1] {
+		t.Errorf("Contexts should not be reused")
+	}
+	if r.delims.Left != "[[" || r.ReadTimeout != time.Second || r.ReadHeaderTimeout != 10*time.Second ||
+		r.WriteTimeout != 2*time.Second || r.MaxHeaderBytes != 4096 {
+		t.Errorf("Template and server settings should be set, was: %+v", r)
+	}
+}
+
+// TestWithJSON tests that WithJSON sets the process wide JSON implementation.
+func TestWithJSON(t *testing.T) {
+	codec := &countingJSON{Core: json.API}
+	defer SetJSON(codec.Core)
+	r := NewWithOptions(WithJSON(codec))
+	r.GET("/", func(c *Context) {
+		c.JSON(200, H{"foo": "bar"})
+	})
+
+	PerformRequest(r, "GET", "/")
+	if codec.encoders != 1 {
+		t.Errorf("JSON implementation should be used once, was used %d times", codec.encoders)
+	}
+}
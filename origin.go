@@ -0,0 +1,77 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrOrigin = errors.New("origin not allowed")
+
+// OriginCheckConfig configures the OriginCheck middleware.
+type OriginCheckConfig struct {
+	// AllowOrigins lists the origins allowed besides the one of the request Host, e.g.
+	// "https://app.example.com". A single "*" inside an origin matches any sequence.
+	AllowOrigins []string
+	// AllowMissing lets through the requests sending neither Origin nor Referer, which
+	// browsers always send on cross-site requests but other clients may not.
+	AllowMissing bool
+	// ErrorHandler answers the rejected requests, by default with 403 Forbidden. It should abort.
+	ErrorHandler func(c *Context)
+}
+
+// OriginCheck returns a middleware verifying that the requests with an unsafe method (anything
+// but GET, HEAD, OPTIONS and TRACE) come from an allowed origin, given by the Origin header or
+// else the Referer. It is a defense in depth next to the CSRF tokens; use one per group to
+// allow different origins.
+func OriginCheck(config OriginCheckConfig) HandlerFunc {
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c *Context) {
+			c.Fail(403, ErrOrigin)
+		}
+	}
+	return func(c *Context) {
+		switch c.Request.Method {
+		case "GET", "HEAD", "OPTIONS", "TRACE":
+			return
+		}
+		origin := c.Request.Header.Get("Origin")
+		if origin == "" {
+			if referer := c.Request.Header.Get("Referer"); referer != "" {
+				if u, err := url.Parse(referer); err == nil && u.Host != "" {
+					origin = u.Scheme + "://" + u.Host
+				} else {
+					origin = "null"
+				}
+			}
+		}
+		if origin == "" {
+			if !config.AllowMissing {
+				config.ErrorHandler(c)
+			}
+			return
+		}
+		if !config.allowOrigin(origin, c.Request.Host) {
+			config.ErrorHandler(c)
+		}
+	}
+}
+
+func (config *OriginCheckConfig) allowOrigin(origin, host string) bool {
+	if origin == "null" {
+		return false
+	}
+	if index := strings.Index(origin, "://"); index >= 0 && strings.EqualFold(origin[index+3:], host) {
+		return true
+	}
+	for _, allowed := range config.AllowOrigins {
+		if matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestOriginCheck tests the verification of the Origin and Referer headers.
+func TestOriginCheck(t *testing.T) {
+	r := New()
+	app := r.Group("/", OriginCheck(OriginCheckConfig{AllowOrigins: []string{"https://*.example.com"}}))
+	app.GET("/", func(c *Context) {})
+	app.POST("/", func(c *Context) {})
+	partner := r.Group("/partner", OriginCheck(OriginCheckConfig{AllowMissing: true}))
+	partner.POST("/hook", func(c *Context) {})
+
+	tests := []struct {
+		method, path, origin, referer string
+		code                          int
+	}{
+		{"GET", "/", "https://evil.com", "", 200},
+		{"POST", "/", "https://app.example.com", "", 200},
+		{"POST", "/", "http://localhost", "", 200},
+		{"POST", "/", "", "https://app.example.com/settings", 200},
+		{"POST", "/", "https://evil.com", "", 403},
+		{"POST", "/", "", "https://evil.com/page", 403},
+		{"POST", "/", "null", "", 403},
+		{"POST", "/", "", "", 403},
+		{"POST", "/partner/hook", "", "", 200},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, "http://localhost"+test.path, nil)
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Referer", test.referer)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s %s from %q %q should get %d, was: %d", test.method, test.path, test.origin, test.referer, test.code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build protobuf
+// +build protobuf
+
+package gin
+
+import "third/gin/render"
+
+// Serializes the given proto.Message as Protocol Buffers into the response body.
+// It also sets the Content-Type as "application/x-protobuf".
+// It is only available when building with the protobuf tag, which needs third/protobuf.
+func (c *Context) ProtoBuf(code int, obj interface{}) {
+	c.Render(code, render.ProtoBuf, obj)
+}
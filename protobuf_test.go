@@ -0,0 +1,60 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build protobuf
+// +build protobuf
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"third/protobuf/proto"
+)
+
+// testMessage is a hand written proto3 message with a single string field.
+type testMessage struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *testMessage) Reset()         { *m = testMessage{} }
+func (m *testMessage) String() string { return "name:" + m.Name }
+func (*testMessage) ProtoMessage()    {}
+
+// TestProtoBuf tests binding a Protocol Buffers body and rendering Protocol Buffers.
+func TestProtoBuf(t *testing.T) {
+	r := New()
+	r.POST("/echo", func(c *Context) {
+		var msg testMessage
+		if c.Bind(&msg) {
+			msg.Name += "!"
+			c.ProtoBuf(200, &msg)
+		}
+	})
+	r.GET("/invalid", func(c *Context) {
+		c.ProtoBuf(200, H{"name": "gin"})
+	})
+
+	body, err := proto.Marshal(&testMessage{Name: "gin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MIMEPROTOBUF)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.HeaderMap.Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("Message should be bound and rendered, was: %d %v", w.Code, w.HeaderMap)
+	}
+	var msg testMessage
+	if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.Name != "gin!" {
+		t.Errorf("Response should be the encoded message, was: %q %v", w.Body.String(), err)
+	}
+
+	if w := PerformRequest(r, "GET", "/invalid"); w.Code != 500 {
+		t.Errorf("Rendering a value that isn't a proto.Message should fail, was: %d", w.Code)
+	}
+}
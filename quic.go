@@ -0,0 +1,33 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build quic
+// +build quic
+
+package gin
+
+import (
+	"log"
+	"net/http"
+	"third/quic-go/http3"
+)
+
+// RunQUIC serves HTTP/3 over QUIC on the UDP port of addr, and HTTPS on its TCP port for the
+// clients without HTTP/3; the HTTPS responses advertise HTTP/3 with an Alt-Svc header.
+// It returns when the HTTPS server stops, e.g. after Shutdown, closing the HTTP/3 server.
+// It is only available when building with the quic tag, which needs third/quic-go.
+func (engine *Engine) RunQUIC(addr, cert, key string) error {
+	debugPrint("Listening and serving HTTP/3 on %s\n", addr)
+	if err := engine.advertiseHTTP3(addr); err != nil {
+		return err
+	}
+	h3 := &http3.Server{Addr: addr, Handler: engine}
+	go func() {
+		if err := h3.ListenAndServeTLS(cert, key); err != nil && err != http.ErrServerClosed {
+			log.Printf("gin: HTTP/3 server on %s: %v", addr, err)
+		}
+	}()
+	defer h3.Close()
+	return engine.RunTLS(addr, cert, key)
+}
@@ -0,0 +1,129 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimitStore keeps the token buckets of the RateLimit middleware. Implement it on top of
+	// a shared store, e.g. a Redis script, to limit across several instances.
+	RateLimitStore interface {
+		// Take consumes a token from the bucket of key, refilled with rate tokens per second up
+		// to burst tokens. When the bucket is empty it returns false and the time until the
+		// next token.
+		Take(key string, rate float64, burst int) (allowed bool, retryAfter time.Duration, err error)
+	}
+
+	// RateLimitConfig configures the RateLimit middleware.
+	RateLimitConfig struct {
+		// Rate is the number of requests allowed per second on average.
+		Rate float64
+		// Burst is the number of requests allowed at once, 1 when zero.
+		Burst int
+		// KeyFunc returns the key limited, c.ClientIP() by default. An empty key isn't limited.
+		KeyFunc func(c *Context) string
+		// Store defaults to a store in memory, local to the process.
+		Store RateLimitStore
+	}
+
+	memoryRateLimitStore struct {
+		mu        sync.Mutex
+		buckets   map[string]*tokenBucket
+		lastSweep time.Time
+		now       func() time.Time
+	}
+
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// RateLimit returns a token bucket rate limiter. Requests over the limit are aborted with
+// 429 Too Many Requests and a Retry-After header. If the store fails the request is let through
+// and the error recorded in c.Errors.
+func RateLimit(config RateLimitConfig) HandlerFunc {
+	if config.Rate <= 0 {
+		panic("rate limit must be positive")
+	}
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = func(c *Context) string { return c.ClientIP() }
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+	return func(c *Context) {
+		key := config.KeyFunc(c)
+		if key == "" {
+			return
+		}
+		allowed, retryAfter, err := config.Store.Take(key, config.Rate, config.Burst)
+		if err != nil {
+			c.ErrorTyped(err, ErrorTypeInternal, "rate limit store")
+			return
+		}
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			c.Writer.Header().Set("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatus(429)
+		}
+	}
+}
+
+// RateLimitByHeader returns a RateLimitConfig.KeyFunc limiting by the value of a request
+// header, e.g. an API key. Requests without the header aren't limited.
+func RateLimitByHeader(name string) func(c *Context) string {
+	return func(c *Context) string {
+		return c.Request.Header.Get(name)
+	}
+}
+
+// NewMemoryRateLimitStore returns a RateLimitStore keeping the buckets in memory.
+// Buckets idle long enough to be full again are dropped.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryRateLimitStore) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	refill := time.Duration(float64(burst) / rate * float64(time.Second))
+	if now.Sub(s.lastSweep) > refill && now.Sub(s.lastSweep) > time.Minute {
+		for k, b := range s.buckets {
+			if now.Sub(b.last) > refill {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRateLimit tests that requests over the burst get 429 with Retry-After, per key.
+func TestRateLimit(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	store.now = func() time.Time { return now }
+
+	r := New()
+	r.Use(RateLimit(RateLimitConfig{Rate: 0.5, Burst: 2, KeyFunc: RateLimitByHeader("X-API-Key"), Store: store}))
+	r.GET("/", func(c *Context) {
+		c.String(200, "ok")
+	})
+
+	perform := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := perform("a"); w.Code != 200 {
+			t.Fatalf("Request %d should be allowed, was: %d", i, w.Code)
+		}
+	}
+	w := perform("a")
+	if w.Code != 429 || w.HeaderMap.Get("Retry-After") != "2" {
+		t.Errorf("Request over the burst should get 429 with Retry-After 2, was: %d %s", w.Code, w.HeaderMap.Get("Retry-After"))
+	}
+	if w := perform("b"); w.Code != 200 {
+		t.Errorf("Other keys should not be limited, was: %d", w.Code)
+	}
+
+	now = now.Add(2 * time.Second)
+	if w := perform("a"); w.Code != 200 {
+		t.Errorf("Request should be allowed once the bucket is refilled, was: %d", w.Code)
+	}
+}
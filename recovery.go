@@ -6,11 +6,17 @@ package gin
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"runtime"
+	"strings"
+	"sync"
+	"syscall"
 )
 
 var (
@@ -35,7 +41,7 @@ func stack(skip int) []byte {
 		// Print this much at least.  If we can't find the source, it won't show.
 		fmt.Fprintf(buf, "%s:%d (0x%x)\n", file, line, pc)
 		if file != lastFile {
-			data, err := ioutil.ReadFile(file)
+			data, err := os.ReadFile(file)
 			if err != nil {
 				continue
 			}
@@ -81,18 +87,94 @@ func function(pc uintptr) []byte {
 	return name
 }
 
+// RecoveryFunc handles the panics recovered by CustomRecovery, e.g. to render a JSON error.
+type RecoveryFunc func(c *Context, err interface{})
+
+var (
+	panicHookMu sync.RWMutex
+	panicHook   func(c *Context, err interface{}, stack []byte)
+)
+
+// SetPanicHook sets the function called with every panic recovered by the Recovery
+// middlewares, e.g. to report them to an error tracking service. nil removes it.
+func SetPanicHook(hook func(c *Context, err interface{}, stack []byte)) {
+	panicHookMu.Lock()
+	panicHook = hook
+	panicHookMu.Unlock()
+}
+
 // Recovery returns a middleware that recovers from any panics and writes a 500 if there was one.
 // While Gin is in development mode, Recovery will also output the panic as HTML.
 func Recovery() HandlerFunc {
+	return CustomRecovery(nil)
+}
+
+// RecoveryConfig configures RecoveryWithConfig.
+type RecoveryConfig struct {
+	// Output receives the panics and their stack, the standard logger by default.
+	Output io.Writer
+	// DisableStack logs the panics without their stack.
+	DisableStack bool
+	// Handler writes the response, a 500 when nil.
+	Handler RecoveryFunc
+}
+
+// CustomRecovery returns a middleware that recovers from any panics, logs them and calls
+// handle to write the response, a 500 when nil.
+func CustomRecovery(handle RecoveryFunc) HandlerFunc {
+	return RecoveryWithConfig(RecoveryConfig{Handler: handle})
+}
+
+// RecoveryWithConfig returns a middleware that recovers from any panics, logs them and calls
+// the handler of config. A panic caused by the client going away (broken pipe, connection
+// reset) is logged on a single line and recorded in c.Errors, without writing a response
+// nor calling the panic hook.
+func RecoveryWithConfig(config RecoveryConfig) HandlerFunc {
+	if config.Handler == nil {
+		config.Handler = func(c *Context, err interface{}) {
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	logf := log.Printf
+	if config.Output != nil {
+		logf = log.New(config.Output, "", log.LstdFlags).Printf
+	}
 	return func(c *Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				c.Abort()
+				if e, ok := err.(error); ok && isBrokenPipe(e) {
+					logf("[GIN] client gone: %s %s: %s", c.Request.Method, c.Request.URL.Path, e)
+					c.ErrorTyped(e, ErrorTypeInternal, "broken pipe")
+					return
+				}
 				stack := stack(3)
-				log.Printf("PANIC: %s\n%s", err, stack)
-				c.Writer.WriteHeader(http.StatusInternalServerError)
+				if config.DisableStack {
+					logf("PANIC: %s", err)
+				} else {
+					logf("PANIC: %s\n%s", err, stack)
+				}
+				panicHookMu.RLock()
+				hook := panicHook
+				panicHookMu.RUnlock()
+				if hook != nil {
+					hook(c, err, stack)
+				}
+				config.Handler(c, err)
 			}
 		}()
 
 		c.Next()
 	}
 }
+
+// isBrokenPipe reports whether err is a write to a connection closed by the client.
+func isBrokenPipe(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) ||
+		strings.Contains(strings.ToLower(opErr.Err.Error()), "broken pipe") ||
+		strings.Contains(strings.ToLower(opErr.Err.Error()), "connection reset by peer")
+}
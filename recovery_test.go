@@ -7,7 +7,10 @@ package gin
 import (
 	"bytes"
 	"log"
+	"net"
 	"os"
+	"strings"
+	"syscall"
 	"testing"
 )
 
@@ -28,7 +31,7 @@ func TestPanicInHandler(t *testing.T) {
 	log.SetOutput(os.Stderr)
 
 	if w.Code != 500 {
-		t.Errorf("Response code should be Internal Server Error, was: %s", w.Code)
+		t.Errorf("Response code should be Internal Server Error, was: %d", w.Code)
 	}
 }
 
@@ -51,6 +54,70 @@ func TestPanicWithAbort(t *testing.T) {
 
 	// TEST
 	if w.Code != 500 {
-		t.Errorf("Response code should be Bad request, was: %s", w.Code)
+		t.Errorf("Response code should be Bad request, was: %d", w.Code)
+	}
+}
+
+// TestCustomRecovery tests the custom panic response and the panic hook.
+func TestCustomRecovery(t *testing.T) {
+	log.SetOutput(bytes.NewBuffer(nil))
+	defer log.SetOutput(os.Stderr)
+	var hooked interface{}
+	var hookedStack []byte
+	SetPanicHook(func(c *Context, err interface{}, stack []byte) {
+		hooked, hookedStack = err, stack
+	})
+	defer SetPanicHook(nil)
+
+	r := New()
+	r.Use(CustomRecovery(func(c *Context, err interface{}) {
+		c.JSON(500, H{"error": err})
+	}))
+	r.GET("/recovery", func(_ *Context) {
+		panic("Oupps")
+	})
+
+	w := PerformRequest(r, "GET", "/recovery")
+	if w.Code != 500 || w.Body.String() != "{\"error\":\"Oupps\"}\n" {
+		t.Errorf("Panic should be handled by the custom handler, was: %d %s", w.Code, w.Body.String())
+	}
+	if hooked != "Oupps" || len(hookedStack) == 0 {
+		t.Errorf("Panic hook should be called with the panic and the stack, was: %v", hooked)
+	}
+}
+
+// TestRecoveryWithConfig tests the output of the panics without their stack.
+func TestRecoveryWithConfig(t *testing.T) {
+	output := new(bytes.Buffer)
+	r := New()
+	r.Use(RecoveryWithConfig(RecoveryConfig{Output: output, DisableStack: true}))
+	r.GET("/recovery", func(_ *Context) {
+		panic("Oupps")
+	})
+
+	if w := PerformRequest(r, "GET", "/recovery"); w.Code != 500 {
+		t.Errorf("Response code should be Internal Server Error, was: %d", w.Code)
+	}
+	if line := output.String(); !strings.Contains(line, "PANIC: Oupps") || strings.Count(line, "\n") != 1 {
+		t.Errorf("Panic should be logged without its stack, was: %q", line)
+	}
+}
+
+// TestRecoveryBrokenPipe tests that panics of clients going away are logged quietly.
+func TestRecoveryBrokenPipe(t *testing.T) {
+	output := new(bytes.Buffer)
+	handled := false
+	r := New()
+	r.Use(RecoveryWithConfig(RecoveryConfig{
+		Output:  output,
+		Handler: func(c *Context, err interface{}) { handled = true },
+	}))
+	r.GET("/recovery", func(_ *Context) {
+		panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+
+	PerformRequest(r, "GET", "/recovery")
+	if handled || !strings.Contains(output.String(), "client gone") || strings.Contains(output.String(), "PANIC") {
+		t.Errorf("Broken pipe should be logged quietly, was: %q", output.String())
 	}
 }
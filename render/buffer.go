@@ -0,0 +1,40 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+// Buffers larger than this are left to the garbage collector instead of going back to the pool,
+// so a single huge response doesn't pin its memory for good.
+const maxPooledBufferSize = 64 * 1024
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// writeBuffered serializes the body with fn into a pooled buffer and writes it once fn succeeded,
+// so a failing render leaves the response untouched for the error handling.
+func writeBuffered(w http.ResponseWriter, code int, contentType string, fn func(buf *bytes.Buffer) error) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := fn(buf); err != nil {
+		return err
+	}
+	writeHeader(w, code, contentType)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
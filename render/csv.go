@@ -0,0 +1,96 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// CSV rows
+type csvRender struct{}
+
+var CSV = csvRender{}
+
+// Render streams data[0] as text/csv. It accepts a [][]string, written as is, or a slice of
+// structs (or pointers to structs) whose header line is generated from the exported fields.
+// The header name is taken from the `csv` tag, falling back to the field name; `csv:"-"` skips a field.
+func (_ csvRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	if records, ok := data[0].([][]string); ok {
+		writeHeader(w, code, "text/csv")
+		writer := csv.NewWriter(w)
+		writer.WriteAll(records)
+		return writer.Error()
+	}
+
+	rows := reflect.ValueOf(data[0])
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		return errors.New("csv: data is not a slice")
+	}
+	elemType := rows.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("csv: data is not a slice of structs")
+	}
+
+	header, fields := csvFields(elemType)
+	writeHeader(w, code, "text/csv")
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(fields))
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		if row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				continue
+			}
+			row = row.Elem()
+		}
+		for j, index := range fields {
+			record[j] = csvValue(row.Field(index))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvFields(typ reflect.Type) (header []string, fields []int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+	return header, fields
+}
+
+func csvValue(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	return fmt.Sprint(value.Interface())
+}
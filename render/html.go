@@ -0,0 +1,72 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"io"
+	"net/http"
+)
+
+type (
+	// HTMLExecutor executes the template called name of a template engine other than
+	// html/template, e.g. pongo2, quicktemplate or plush.
+	HTMLExecutor interface {
+		Execute(w io.Writer, name string, data interface{}) error
+	}
+
+	// HTMLExecutorFunc adapts a function to the HTMLExecutor interface.
+	HTMLExecutorFunc func(w io.Writer, name string, data interface{}) error
+
+	// Adapter from an HTMLExecutor to Engine.HTMLRender
+	htmlAdapterRender struct {
+		executor HTMLExecutor
+	}
+)
+
+func (f HTMLExecutorFunc) Execute(w io.Writer, name string, data interface{}) error {
+	return f(w, name, data)
+}
+
+// HTMLAdapter returns a Render usable as Engine.HTMLRender that keeps the c.HTML semantics,
+// the page being written as "text/html" with the given status code. For instance with pongo2:
+//
+//	engine.HTMLRender = render.HTMLAdapter(render.HTMLExecutorFunc(
+//		func(w io.Writer, name string, data interface{}) error {
+//			tmpl, err := pongo2.FromCache(name)
+//			if err != nil {
+//				return err
+//			}
+//			return tmpl.ExecuteWriter(data.(pongo2.Context), w)
+//		}))
+//
+// quicktemplate pages are plain functions, dispatched on name:
+//
+//	render.HTMLExecutorFunc(func(w io.Writer, name string, data interface{}) error {
+//		switch name {
+//		case "index":
+//			templates.WriteIndex(w, data.(*templates.IndexPage))
+//			return nil
+//		}
+//		return fmt.Errorf("unknown page %s", name)
+//	})
+//
+// and plush templates are rendered from their source:
+//
+//	render.HTMLExecutorFunc(func(w io.Writer, name string, data interface{}) error {
+//		out, err := plush.Render(sources[name], plush.NewContextWith(data.(map[string]interface{})))
+//		if err != nil {
+//			return err
+//		}
+//		_, err = io.WriteString(w, out)
+//		return err
+//	})
+func HTMLAdapter(executor HTMLExecutor) Render {
+	return htmlAdapterRender{executor}
+}
+
+func (r htmlAdapterRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	writeHeader(w, code, "text/html")
+	return r.executor.Execute(w, data[0].(string), data[1])
+}
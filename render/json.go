@@ -0,0 +1,131 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"third/gin/codec/json"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type (
+	// JSON with an anti-hijacking prefix for top level arrays
+	secureJSONRender struct{}
+
+	// JSON wrapped in a javascript callback
+	jsonpRender struct{}
+
+	// Pretty printed JSON
+	indentedJSONRender struct{}
+
+	// JSON without HTML escaping
+	pureJSONRender struct{}
+
+	// JSON with every non-ASCII character escaped
+	asciiJSONRender struct{}
+)
+
+// ErrJSONPCallback is returned by the JSONP render for callback names that aren't a javascript
+// identifier or dotted path, e.g. "jQuery123" or "app.onData".
+var ErrJSONPCallback = errors.New("render: invalid JSONP callback")
+
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+// ValidJSONPCallback reports whether name can be used as a JSONP callback.
+func ValidJSONPCallback(name string) bool {
+	return len(name) <= 128 && jsonpCallback.MatchString(name)
+}
+
+var (
+	SecureJSON   = secureJSONRender{}
+	JSONP        = jsonpRender{}
+	IndentedJSON = indentedJSONRender{}
+	PureJSON     = pureJSONRender{}
+	AsciiJSON    = asciiJSONRender{}
+)
+
+// Render writes data[0] as JSON. When it encodes to a top level array, data[1] (the prefix,
+// e.g. "while(1);") is written first so the response can't be evaluated as a script.
+func (_ secureJSONRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	return writeBuffered(w, code, "application/json", func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.API.Marshal(data[0])
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(jsonBytes, []byte("[")) {
+			buf.WriteString(data[1].(string))
+		}
+		buf.Write(jsonBytes)
+		return nil
+	})
+}
+
+// Render writes data[0] as JSON wrapped in a call to the callback named data[1].
+// Callback names that aren't valid, see ValidJSONPCallback, fail with ErrJSONPCallback.
+func (_ jsonpRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	callback := data[1].(string)
+	if !ValidJSONPCallback(callback) {
+		return ErrJSONPCallback
+	}
+	return writeBuffered(w, code, "application/javascript", func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.API.Marshal(data[0])
+		if err != nil {
+			return err
+		}
+		buf.WriteString(callback + "(")
+		buf.Write(jsonBytes)
+		buf.WriteString(");")
+		return nil
+	})
+}
+
+func (_ indentedJSONRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	return writeBuffered(w, code, "application/json", func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.API.MarshalIndent(data[0], "", "    ")
+		if err != nil {
+			return err
+		}
+		buf.Write(jsonBytes)
+		return nil
+	})
+}
+
+// Render writes data[0] as JSON keeping '<', '>' and '&' as literal characters
+// instead of escaping them to \u003c, \u003e and \u0026.
+func (_ pureJSONRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	return writeBuffered(w, code, "application/json", func(buf *bytes.Buffer) error {
+		encoder := json.API.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(data[0])
+	})
+}
+
+// Render writes data[0] as JSON escaping every non-ASCII character to a \uXXXX sequence
+// (surrogate pairs outside the BMP), so the body is pure ASCII.
+func (_ asciiJSONRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	return writeBuffered(w, code, "application/json", func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.API.Marshal(data[0])
+		if err != nil {
+			return err
+		}
+		for _, r := range string(jsonBytes) {
+			switch {
+			case r < utf8.RuneSelf:
+				buf.WriteByte(byte(r))
+			case r > 0xFFFF:
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(buf, "\\u%04x\\u%04x", r1, r2)
+			default:
+				fmt.Fprintf(buf, "\\u%04x", r)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+)
+
+// HTMLLayouts is an Engine.HTMLRender composing every page from a base layout and the files
+// defining its blocks. c.HTML selects the page by the name it was added with:
+//
+//	layouts := render.NewHTMLLayouts()
+//	layouts.AddFromFiles("home", "layouts/base.html", "pages/home.html")
+//	layouts.AddFromFiles("admin/users", "layouts/admin.html", "pages/users.html", "partials/table.html")
+//	engine.HTMLRender = layouts
+//
+//	c.HTML(200, "home", data)
+//
+// The layout references the blocks with {{template "content" .}} or {{block "content" .}}{{end}},
+// the page files redefine them with {{define "content"}}...{{end}}.
+type HTMLLayouts map[string]*template.Template
+
+func NewHTMLLayouts() HTMLLayouts {
+	return HTMLLayouts{}
+}
+
+// Add registers a page already parsed, executed from its root template.
+func (l HTMLLayouts) Add(name string, tmpl *template.Template) {
+	if tmpl == nil {
+		panic("template can not be nil")
+	}
+	if _, ok := l[name]; ok {
+		panic(fmt.Sprintf("template %s already exists", name))
+	}
+	l[name] = tmpl
+}
+
+// AddFromFiles parses the page called name from the layout file followed by the files
+// defining its blocks and partials. It panics if a file can't be parsed.
+func (l HTMLLayouts) AddFromFiles(name, layout string, files ...string) *template.Template {
+	return l.AddFromFilesFuncs(name, nil, layout, files...)
+}
+
+// AddFromFilesFuncs is AddFromFiles with the functions of funcMap available to the templates.
+func (l HTMLLayouts) AddFromFilesFuncs(name string, funcMap template.FuncMap, layout string, files ...string) *template.Template {
+	tmpl := template.New(filepath.Base(layout)).Funcs(funcMap)
+	tmpl = template.Must(tmpl.ParseFiles(append([]string{layout}, files...)...))
+	l.Add(name, tmpl)
+	return tmpl
+}
+
+func (l HTMLLayouts) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	name := data[0].(string)
+	tmpl, ok := l[name]
+	if !ok {
+		return fmt.Errorf("html/template: %q is undefined", name)
+	}
+	return writeBuffered(w, code, "text/html", func(buf *bytes.Buffer) error {
+		return tmpl.Execute(buf, data[1])
+	})
+}
@@ -0,0 +1,32 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build msgpack
+// +build msgpack
+
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"third/msgpack"
+)
+
+// MessagePack
+type msgpackRender struct{}
+
+// MsgPack writes data[0] as MessagePack. It is only available when building with the msgpack
+// tag, which needs third/msgpack.
+var MsgPack = msgpackRender{}
+
+func (_ msgpackRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	var buf bytes.Buffer
+	if err := msgpack.NewEncoder(&buf).Encode(data[0]); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-msgpack")
+	w.WriteHeader(code)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,36 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build protobuf
+// +build protobuf
+
+package render
+
+import (
+	"errors"
+	"net/http"
+	"third/protobuf/proto"
+)
+
+// Protocol Buffers
+type protobufRender struct{}
+
+// ProtoBuf writes data[0], a proto.Message, as Protocol Buffers. It is only available when
+// building with the protobuf tag, which needs third/protobuf.
+var ProtoBuf = protobufRender{}
+
+func (_ protobufRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	msg, ok := data[0].(proto.Message)
+	if !ok {
+		return errors.New("data is not a proto.Message")
+	}
+	bytes, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(code)
+	_, err = w.Write(bytes)
+	return err
+}
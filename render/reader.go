@@ -0,0 +1,52 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type (
+	// Streams a body from an io.Reader
+	readerRender struct{}
+
+	// ReaderData describes a body copied from Reader without buffering it in memory.
+	// A negative ContentLength omits the Content-Length header, otherwise only ContentLength
+	// bytes of Reader are sent.
+	ReaderData struct {
+		ContentType   string
+		ContentLength int64
+		Reader        io.Reader
+		Headers       map[string]string
+	}
+)
+
+var Reader = readerRender{}
+
+func (_ readerRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	r := data[0].(ReaderData)
+	header := w.Header()
+	for k, v := range r.Headers {
+		if len(header.Get(k)) == 0 {
+			header.Set(k, v)
+		}
+	}
+	if len(r.ContentType) > 0 {
+		header.Set("Content-Type", r.ContentType)
+	}
+	if r.ContentLength >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(r.ContentLength, 10))
+	}
+	w.WriteHeader(code)
+	if r.ContentLength >= 0 {
+		// a longer reader would make the response overrun its Content-Length
+		_, err := io.CopyN(w, r.Reader, r.ContentLength)
+		return err
+	}
+	_, err := io.Copy(w, r.Reader)
+	return err
+}
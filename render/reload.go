@@ -0,0 +1,130 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTMLWatcher is an Engine.HTMLRender reparsing its templates when the files matched by its
+// globs change, whatever the gin mode. The files are polled every interval; a new template set
+// is swapped in atomically once parsed, requests already rendering keep the previous one.
+// A set that fails to parse is ignored, see Err, and the previous one stays in use.
+type HTMLWatcher struct {
+	globs    []string
+	parse    func() (*template.Template, error)
+	current  atomic.Value // *template.Template
+	mu       sync.Mutex
+	stamp    string
+	err      error
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewHTMLWatcher parses the templates with parse and starts polling the files matched by globs.
+// An interval <= 0 disables polling, Reload then has to be called explicitly.
+func NewHTMLWatcher(globs []string, interval time.Duration, parse func() (*template.Template, error)) (*HTMLWatcher, error) {
+	w := &HTMLWatcher{
+		globs: globs,
+		parse: parse,
+		stop:  make(chan struct{}),
+	}
+	stamp, err := w.filesStamp()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	w.stamp = stamp
+	w.current.Store(tmpl)
+	if interval > 0 {
+		go w.watch(interval)
+	}
+	return w, nil
+}
+
+func (w *HTMLWatcher) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Reload()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Reload reparses the templates if a watched file was added, removed or modified.
+func (w *HTMLWatcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stamp, err := w.filesStamp()
+	if err != nil {
+		w.err = err
+		return err
+	}
+	if stamp == w.stamp {
+		return nil
+	}
+	tmpl, err := w.parse()
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.stamp = stamp
+	w.err = nil
+	w.current.Store(tmpl)
+	return nil
+}
+
+// Err returns the error of the last failed reload, nil once a reload succeeds.
+func (w *HTMLWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Stop stops polling the files. The last parsed templates keep being rendered.
+func (w *HTMLWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// filesStamp sums up the name, size and modification time of every watched file.
+func (w *HTMLWatcher) filesStamp() (string, error) {
+	stamp := ""
+	for _, glob := range w.globs {
+		files, err := filepath.Glob(glob)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return "", err
+			}
+			stamp += fmt.Sprintf("%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return stamp, nil
+}
+
+func (w *HTMLWatcher) Render(rw http.ResponseWriter, code int, data ...interface{}) error {
+	tmpl := w.current.Load().(*template.Template)
+	return writeBuffered(rw, code, "text/html", func(buf *bytes.Buffer) error {
+		return tmpl.ExecuteTemplate(buf, data[0].(string), data[1])
+	})
+}
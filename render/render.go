@@ -5,14 +5,18 @@
 package render
 
 import (
-	"encoding/json"
-	"encoding/xml"
+	"bytes"
 	"fmt"
 	"html/template"
 	"net/http"
+	"third/gin/codec/json"
 )
 
 type (
+	// Render is implemented by everything that writes a response body. It receives the status
+	// code and the values passed to Context.Render; Context.HTML passes the template name and
+	// its data, so any template engine can back Engine.HTMLRender, see HTMLAdapter.
+	// Render must set the Content-Type and write the status code before the body.
 	Render interface {
 		Render(http.ResponseWriter, int, ...interface{}) error
 	}
@@ -20,9 +24,6 @@ type (
 	// JSON binding
 	jsonRender struct{}
 
-	// XML binding
-	xmlRender struct{}
-
 	// Plain text
 	plainRender struct{}
 
@@ -34,8 +35,16 @@ type (
 
 	// Redirects
 	htmlDebugRender struct {
-		files []string
-		globs []string
+		files   []string
+		globs   []string
+		funcMap template.FuncMap
+		delims  Delims
+	}
+
+	// Delims are the action delimiters of the HTML templates, "{{" and "}}" when empty.
+	Delims struct {
+		Left  string
+		Right string
 	}
 
 	// form binding
@@ -46,7 +55,6 @@ type (
 
 var (
 	JSON      = jsonRender{}
-	XML       = xmlRender{}
 	Plain     = plainRender{}
 	HTMLPlain = htmlPlainRender{}
 	Redirect  = redirectRender{}
@@ -59,23 +67,30 @@ func writeHeader(w http.ResponseWriter, code int, contentType string) {
 }
 
 func (_ jsonRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
-	writeHeader(w, code, "application/json")
-	encoder := json.NewEncoder(w)
-	return encoder.Encode(data[0])
+	return writeBuffered(w, code, "application/json", func(buf *bytes.Buffer) error {
+		return json.API.NewEncoder(buf).Encode(data[0])
+	})
 }
 
+// Render writes a redirect to data[0]. When the originating *http.Request is passed as data[1]
+// relative locations are resolved with http.Redirect, otherwise only the Location header is set.
+// Codes outside 300-308 (or 201 Created) are a programming error and panic.
 func (_ redirectRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
-	w.Header().Set("Location", data[0].(string))
+	if (code < 300 || code > 308) && code != 201 {
+		panic(fmt.Sprintf("Cannot redirect with status code %d", code))
+	}
+	location := data[0].(string)
+	if len(data) > 1 {
+		if req, ok := data[1].(*http.Request); ok && req != nil {
+			http.Redirect(w, req, location, code)
+			return nil
+		}
+	}
+	w.Header().Set("Location", location)
 	w.WriteHeader(code)
 	return nil
 }
 
-func (_ xmlRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
-	writeHeader(w, code, "application/xml")
-	encoder := xml.NewEncoder(w)
-	return encoder.Encode(data[0])
-}
-
 func (_ plainRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
 	writeHeader(w, code, "text/plain")
 	format := data[0].(string)
@@ -110,12 +125,21 @@ func (r *htmlDebugRender) AddFiles(files ...string) {
 	r.files = append(r.files, files...)
 }
 
+// SetFuncMap sets the functions made available to the templates parsed on each render.
+func (r *htmlDebugRender) SetFuncMap(funcMap template.FuncMap) {
+	r.funcMap = funcMap
+}
+
+// SetDelims sets the action delimiters of the templates parsed on each render.
+func (r *htmlDebugRender) SetDelims(delims Delims) {
+	r.delims = delims
+}
+
 func (r *htmlDebugRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
-	writeHeader(w, code, "text/html")
 	file := data[0].(string)
 	obj := data[1]
 
-	t := template.New("")
+	t := template.New("").Delims(r.delims.Left, r.delims.Right).Funcs(r.funcMap)
 
 	if len(r.files) > 0 {
 		if _, err := t.ParseFiles(r.files...); err != nil {
@@ -129,12 +153,15 @@ func (r *htmlDebugRender) Render(w http.ResponseWriter, code int, data ...interf
 		}
 	}
 
-	return t.ExecuteTemplate(w, file, obj)
+	return writeBuffered(w, code, "text/html", func(buf *bytes.Buffer) error {
+		return t.ExecuteTemplate(buf, file, obj)
+	})
 }
 
 func (html HTMLRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
-	writeHeader(w, code, "text/html")
 	file := data[0].(string)
 	obj := data[1]
-	return html.Template.ExecuteTemplate(w, file, obj)
+	return writeBuffered(w, code, "text/html", func(buf *bytes.Buffer) error {
+		return html.Template.ExecuteTemplate(buf, file, obj)
+	})
 }
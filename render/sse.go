@@ -0,0 +1,86 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"third/gin/codec/json"
+)
+
+type (
+	// Server-Sent Events
+	sseRender struct{}
+
+	// SSEvent is a single Server-Sent Event. Data is written as-is when it is a string
+	// and encoded as JSON otherwise.
+	SSEvent struct {
+		Event string
+		Id    string
+		Retry uint
+		Data  interface{}
+	}
+)
+
+var SSE = sseRender{}
+
+var sseReplacer = strings.NewReplacer("\n", "\\n", "\r", "\\r")
+
+func (_ sseRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	header := w.Header()
+	if len(header.Get("Content-Type")) == 0 {
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+	}
+	w.WriteHeader(code)
+
+	for _, d := range data {
+		if err := encodeSSEvent(w, d.(SSEvent)); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func encodeSSEvent(w io.Writer, event SSEvent) error {
+	var buf bytes.Buffer
+	if len(event.Id) > 0 {
+		buf.WriteString("id:" + sseReplacer.Replace(event.Id) + "\n")
+	}
+	if len(event.Event) > 0 {
+		buf.WriteString("event:" + sseReplacer.Replace(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&buf, "retry:%d\n", event.Retry)
+	}
+
+	var payload string
+	switch d := event.Data.(type) {
+	case string:
+		payload = d
+	case []byte:
+		payload = string(d)
+	default:
+		b, err := json.API.Marshal(d)
+		if err != nil {
+			return err
+		}
+		payload = string(b)
+	}
+	for _, line := range strings.Split(strings.Replace(payload, "\r\n", "\n", -1), "\n") {
+		buf.WriteString("data:" + line + "\n")
+	}
+	buf.WriteString("\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,63 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"html/template"
+	"net/http"
+)
+
+const defaultStreamFlushSize = 32 * 1024
+
+// HTMLStreamRender executes the templates straight to the client, flushing the output every
+// FlushSize bytes (32KB by default) so large pages are neither buffered nor held back.
+// When the execution fails mid-stream the status code is already sent; the Fallback template,
+// if any, is then executed with the error as data to close the page, and the error is returned.
+type HTMLStreamRender struct {
+	Template  *template.Template
+	FlushSize int
+	Fallback  string
+}
+
+func (r HTMLStreamRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	writeHeader(w, code, "text/html")
+	size := r.FlushSize
+	if size <= 0 {
+		size = defaultStreamFlushSize
+	}
+	fw := &flushWriter{w: w, size: size}
+	fw.flusher, _ = w.(http.Flusher)
+
+	err := r.Template.ExecuteTemplate(fw, data[0].(string), data[1])
+	if err != nil && r.Fallback != "" {
+		r.Template.ExecuteTemplate(fw, r.Fallback, err)
+	}
+	fw.flush()
+	return err
+}
+
+// flushWriter flushes the underlying writer every time size bytes were written.
+type flushWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	size    int
+	pending int
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.pending += n
+	if fw.pending >= fw.size {
+		fw.flush()
+	}
+	return n, err
+}
+
+func (fw *flushWriter) flush() {
+	if fw.flusher != nil {
+		fw.flusher.Flush()
+	}
+	fw.pending = 0
+}
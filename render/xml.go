@@ -0,0 +1,133 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type (
+	// XML binding
+	xmlRender struct{}
+
+	// XMLOptions customizes the XML render. The zero value keeps the plain encoding/xml output.
+	XMLOptions struct {
+		// RootName replaces the root element name, "map" for maps and the type name otherwise.
+		RootName string
+		// AttrPrefix marks the map keys written as attributes of the enclosing element,
+		// e.g. with "@" the key "@id" becomes id="...".
+		AttrPrefix string
+		// Header writes the <?xml ...?> declaration before the document.
+		Header bool
+		// Encoding is the encoding named in the declaration, UTF-8 by default.
+		// The body itself is not transcoded.
+		Encoding string
+	}
+)
+
+var XML = xmlRender{}
+
+// Render writes data[0] as XML, customized by the XMLOptions optionally passed as data[1].
+// Maps with string keys are written with their keys sorted, nested maps becoming child elements.
+func (_ xmlRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	var opts XMLOptions
+	if len(data) > 1 {
+		opts, _ = data[1].(XMLOptions)
+	}
+	return writeBuffered(w, code, "application/xml", func(buf *bytes.Buffer) error {
+		return encodeXML(buf, data[0], opts)
+	})
+}
+
+func encodeXML(w io.Writer, obj interface{}, opts XMLOptions) error {
+	if opts.Header {
+		encoding := opts.Encoding
+		if encoding == "" {
+			encoding = "UTF-8"
+		}
+		if _, err := io.WriteString(w, `<?xml version="1.0" encoding="`+encoding+`"?>`+"\n"); err != nil {
+			return err
+		}
+	}
+	encoder := xml.NewEncoder(w)
+	if opts.RootName == "" && opts.AttrPrefix == "" {
+		return encoder.Encode(obj)
+	}
+	value := reflect.ValueOf(obj)
+	if isStringMap(value) {
+		name := opts.RootName
+		if name == "" {
+			name = "map"
+		}
+		if err := encodeXMLMap(encoder, name, value, opts.AttrPrefix); err != nil {
+			return err
+		}
+		return encoder.Flush()
+	}
+	start := xml.StartElement{}
+	if opts.RootName != "" {
+		start.Name.Local = opts.RootName
+	}
+	return encoder.EncodeElement(obj, start)
+}
+
+func isStringMap(value reflect.Value) bool {
+	return value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String
+}
+
+func encodeXMLMap(encoder *xml.Encoder, name string, value reflect.Value, attrPrefix string) error {
+	keys := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	children := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if attrPrefix != "" && strings.HasPrefix(key, attrPrefix) {
+			elem := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+			start.Attr = append(start.Attr, xml.Attr{
+				Name:  xml.Name{Local: strings.TrimPrefix(key, attrPrefix)},
+				Value: fmt.Sprint(elem.Interface()),
+			})
+			continue
+		}
+		children = append(children, key)
+	}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, key := range children {
+		elem := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+		for elem.Kind() == reflect.Interface && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		if isStringMap(elem) {
+			if err := encodeXMLMap(encoder, key, elem, attrPrefix); err != nil {
+				return err
+			}
+			continue
+		}
+		if !elem.IsValid() || (elem.Kind() == reflect.Interface && elem.IsNil()) {
+			if err := encoder.EncodeElement("", xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := encoder.EncodeElement(elem.Interface(), xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
@@ -0,0 +1,32 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build yaml
+// +build yaml
+
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"third/yaml"
+)
+
+// YAML
+type yamlRender struct{}
+
+// YAML writes data[0] as YAML. It is only available when building with the yaml tag, which
+// needs third/yaml.
+var YAML = yamlRender{}
+
+func (_ yamlRender) Render(w http.ResponseWriter, code int, data ...interface{}) error {
+	return writeBuffered(w, code, "application/x-yaml", func(buf *bytes.Buffer) error {
+		yamlBytes, err := yaml.Marshal(data[0])
+		if err != nil {
+			return err
+		}
+		buf.Write(yamlBytes)
+		return nil
+	})
+}
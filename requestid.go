@@ -0,0 +1,60 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	RequestIDKey     = "request_id"
+	HeaderXRequestID = "X-Request-ID"
+)
+
+// RequestID returns a middleware giving every request an ID: the X-Request-ID header sent by
+// the client or an upstream service when it is well formed, a new random one otherwise.
+// The ID is stored under RequestIDKey, see c.RequestID, sent back in the X-Request-ID response
+// header and printed by the Logger.
+func RequestID() HandlerFunc {
+	return func(c *Context) {
+		id := c.Request.Header.Get(HeaderXRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Writer.Header().Set(HeaderXRequestID, id)
+	}
+}
+
+// RequestID returns the ID set by the RequestID middleware, empty without it.
+func (c *Context) RequestID() string {
+	if id, err := c.Get(RequestIDKey); err == nil {
+		if s, ok := id.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// validRequestID accepts up to 128 printable ASCII characters, so forged IDs can't inject
+// anything in the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
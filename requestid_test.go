@@ -0,0 +1,46 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRequestID tests that incoming IDs are kept and missing or malformed ones generated.
+func TestRequestID(t *testing.T) {
+	var id string
+	r := New()
+	r.Use(RequestID())
+	r.GET("/", func(c *Context) {
+		id = c.RequestID()
+	})
+
+	perform := func(incoming string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if incoming != "" {
+			req.Header.Set(HeaderXRequestID, incoming)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := perform("abc-123")
+	if id != "abc-123" || w.HeaderMap.Get(HeaderXRequestID) != "abc-123" {
+		t.Errorf("Incoming request ID should be kept, was: %s %s", id, w.HeaderMap.Get(HeaderXRequestID))
+	}
+
+	w = perform("")
+	if len(id) != 32 || w.HeaderMap.Get(HeaderXRequestID) != id {
+		t.Errorf("Request ID should be generated, was: %s %s", id, w.HeaderMap.Get(HeaderXRequestID))
+	}
+
+	w = perform("bad id\r\n")
+	if id == "bad id\r\n" || len(id) != 32 {
+		t.Errorf("Malformed request ID should be replaced, was: %q", id)
+	}
+}
@@ -6,6 +6,7 @@ package gin
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"log"
 	"net"
@@ -98,3 +99,90 @@ func (w *responseWriter) Flush() {
 		flusher.Flush()
 	}
 }
+
+// bufferedWriter holds back the status and the body written by the handlers, so middlewares
+// can inspect or transform the whole response before sending it with flush.
+// A handler calling Flush switches it to pass-through: what was buffered is sent and
+// the rest of the response streams to the client.
+type bufferedWriter struct {
+	ResponseWriter
+	status    int
+	written   bool
+	streaming bool
+	body      bytes.Buffer
+}
+
+func newBufferedWriter(w ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, status: w.Status()}
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.streaming {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return NoWritten
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	if w.streaming {
+		return w.ResponseWriter.Written()
+	}
+	return w.written
+}
+
+func (w *bufferedWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// flush sends the buffered status and body, if the handlers wrote anything.
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if !w.written {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+	w.body.Reset()
+}
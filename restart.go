@@ -0,0 +1,174 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// restartFDEnv passes the listening socket to the process started by a restart.
+	restartFDEnv = "GIN_RESTART_FD"
+	// restartReadyEnv passes the pipe the process started by a restart writes to once serving.
+	restartReadyEnv = "GIN_RESTART_READY"
+	// restartReadyTimeout is how long a restart waits for the new process to be serving.
+	restartReadyTimeout = 30 * time.Second
+)
+
+// RunGraceful is like Run, with zero-downtime restarts: on SIGUSR2 it starts the binary again,
+// possibly replaced by a new version, handing it the listening socket so no connection is
+// refused, waits for it to be serving with its start hooks run, then stops accepting and
+// drains the requests in flight before returning. If the new process fails to start or exits
+// first, the current one keeps serving. On SIGINT or SIGTERM it drains and returns too. The
+// drain waits up to GracefulExitTimeout. The caller should exit when RunGraceful returns nil.
+func (engine *Engine) RunGraceful(addr string) error {
+	ln, err := inheritedListener()
+	if err != nil {
+		return err
+	}
+	if ln == nil {
+		if ln, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	} else {
+		log.Printf("gin: [%d] serving on the inherited socket %s", os.Getpid(), ln.Addr())
+	}
+
+	// the process which restarted this one starts draining once the start hooks ran
+	engine.OnStart(notifyReady)
+	done := make(chan error, 1)
+	go func() { done <- engine.RunListener(ln) }()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR2, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case err := <-done:
+			return err
+		case s := <-sig:
+			if s == syscall.SIGUSR2 {
+				if err := restart(ln); err != nil {
+					log.Printf("gin: [%d] restart failed, still serving: %v", os.Getpid(), err)
+					continue
+				}
+			}
+			log.Printf("gin: [%d] graceful exit action from signal [%s]", os.Getpid(), s)
+			timeout := engine.GracefulExitTimeout
+			if timeout <= 0 {
+				timeout = DefaultGracefulExitTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err := engine.Shutdown(ctx)
+			cancel()
+			engine.gracefulExit(timeout)
+			<-done
+			return err
+		}
+	}
+}
+
+// restart starts the current binary with the same arguments, passing it the listening socket,
+// and waits for the new process to be serving.
+func restart(ln net.Listener) error {
+	filer, ok := ln.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return &net.OpError{Op: "restart", Net: ln.Addr().Network(), Addr: ln.Addr(), Err: syscall.EINVAL}
+	}
+	f, err := filer.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+	path, err := os.Executable()
+	if err != nil {
+		readyW.Close()
+		return err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// the extra files are the descriptors 3 and 4 of the child
+	cmd.ExtraFiles = []*os.File{f, readyW}
+	cmd.Env = append(os.Environ(), restartFDEnv+"=3", restartReadyEnv+"=4")
+	err = cmd.Start()
+	// only the child keeps the write end, so the read fails if it exits before being ready
+	readyW.Close()
+	if err != nil {
+		return err
+	}
+	log.Printf("gin: [%d] restarted as %d", os.Getpid(), cmd.Process.Pid)
+	go cmd.Wait()
+	if err := waitReady(ready, restartReadyTimeout); err != nil {
+		cmd.Process.Kill()
+		return err
+	}
+	return nil
+}
+
+// waitReady waits up to timeout for the process started by a restart to write to ready.
+func waitReady(ready *os.File, timeout time.Duration) error {
+	if err := ready.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if n, err := ready.Read(make([]byte, 1)); n == 0 {
+		if err == nil || err == io.EOF {
+			err = errors.New("gin: the new process exited before serving")
+		}
+		return err
+	}
+	return nil
+}
+
+// notifyReady tells the process which restarted this one that it is serving, if any did.
+func notifyReady() {
+	value := os.Getenv(restartReadyEnv)
+	if value == "" {
+		return
+	}
+	os.Unsetenv(restartReadyEnv)
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	f.Write([]byte{1})
+	f.Close()
+}
+
+// inheritedListener returns the listening socket passed by a restart, nil if none was.
+func inheritedListener() (net.Listener, error) {
+	value := os.Getenv(restartFDEnv)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(restartFDEnv)
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), "listener")
+	defer f.Close()
+	return net.FileListener(f)
+}
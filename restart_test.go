@@ -0,0 +1,131 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package gin
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestInheritedListener tests getting back the socket passed by a restart.
+func TestInheritedListener(t *testing.T) {
+	if ln, err := inheritedListener(); ln != nil || err != nil {
+		t.Fatalf("No socket should be inherited, was: %v %v", ln, err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(restartFDEnv, strconv.Itoa(fd))
+	inherited, err := inheritedListener()
+	if err != nil || inherited == nil {
+		t.Fatalf("Socket should be inherited, was: %v", err)
+	}
+	defer inherited.Close()
+	if inherited.Addr().String() != ln.Addr().String() {
+		t.Errorf("Inherited socket should listen on %s, was: %s", ln.Addr(), inherited.Addr())
+	}
+	if os.Getenv(restartFDEnv) != "" {
+		t.Errorf("Environment variable should be cleared for the children")
+	}
+}
+
+// TestRestartReady tests that a restart waits for the new process to be serving.
+func TestRestartReady(t *testing.T) {
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ready.Close()
+	fd, err := syscall.Dup(int(readyW.Fd()))
+	readyW.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(restartReadyEnv, strconv.Itoa(fd))
+	notifyReady()
+	if err := waitReady(ready, time.Second); err != nil {
+		t.Errorf("Restart should be ready, was: %v", err)
+	}
+	if os.Getenv(restartReadyEnv) != "" {
+		t.Errorf("Environment variable should be cleared for the children")
+	}
+
+	exited, exitedW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exited.Close()
+	exitedW.Close()
+	if err := waitReady(exited, time.Second); err == nil {
+		t.Errorf("Restart should fail when the new process exits first")
+	}
+
+	slow, slowW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+	defer slowW.Close()
+	if err := waitReady(slow, 10*time.Millisecond); err == nil {
+		t.Errorf("Restart should fail when the new process isn't serving in time")
+	}
+}
+
+// TestRunGracefulReady tests that the process started by a restart is ready once its start
+// hooks ran.
+func TestRunGracefulReady(t *testing.T) {
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ready.Close()
+	fd, err := syscall.Dup(int(readyW.Fd()))
+	readyW.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(restartReadyEnv, strconv.Itoa(fd))
+
+	var started int32
+	r := New()
+	r.OnStart(func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&started, 1)
+	})
+	done := make(chan error, 1)
+	go func() { done <- r.RunGraceful("127.0.0.1:0") }()
+
+	if err := waitReady(ready, 5*time.Second); err != nil {
+		t.Fatalf("Restart should be ready, was: %v", err)
+	}
+	if atomic.LoadInt32(&started) != 1 {
+		t.Errorf("Restart should be ready only once the start hooks ran")
+	}
+	r.Shutdown(context.Background())
+	if err := <-done; err != nil {
+		t.Errorf("RunGraceful should return nil, was: %v", err)
+	}
+}
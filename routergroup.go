@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"path"
 	"sync"
+	"sync/atomic"
 	"third/httprouter"
 )
 
@@ -76,14 +77,16 @@ func (group *RouterGroup) Handle(httpMethod, relativePath string, handlers []Han
 	group.engine.router.Handle(httpMethod, absolutePath, func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
 		if !isExiting() {
 			wgReqs.Add(1)
+			atomic.AddInt64(&numReqs, 1)
 			defer wgReqs.Done()
+			defer atomic.AddInt64(&numReqs, -1)
 
-			context := group.engine.createContext(w, req, params, handlers)
+			context := group.engine.createContext(w, req, params, absolutePath, handlers)
 			context.Next()
 			context.Writer.WriteHeaderNow()
 			group.engine.reuseContext(context)
 		} else {
-			context := group.engine.createContext(w, req, params, handlers)
+			context := group.engine.createContext(w, req, params, absolutePath, handlers)
 			context.AbortWithStatus(http.StatusInternalServerError)
 			fmt.Fprint(context.Writer, "server is exiting, new request is rejected")
 			group.engine.reuseContext(context)
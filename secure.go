@@ -0,0 +1,94 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strconv"
+	"time"
+)
+
+const secureConfigKey = "_gin-gonic/gin/secureconfig"
+
+// SecureConfig configures the Secure middleware. The empty fields get the documented default,
+// and a header is left out by setting its field to "-" (a negative HSTSMaxAge for HSTS).
+type SecureConfig struct {
+	// HSTSMaxAge is the Strict-Transport-Security max-age, 365 days by default. HSTS is only
+	// sent over TLS, or when X-Forwarded-Proto is https.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// ContentTypeOptions defaults to "nosniff".
+	ContentTypeOptions string
+	// FrameOptions defaults to "DENY".
+	FrameOptions string
+	// ReferrerPolicy defaults to "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+	// ContentSecurityPolicy defaults to "default-src 'self'".
+	ContentSecurityPolicy string
+}
+
+// Secure returns a middleware setting the security headers of the responses: HSTS,
+// X-Content-Type-Options, X-Frame-Options, Referrer-Policy and Content-Security-Policy.
+// Routes and groups needing other values use SecureOverride after it.
+func Secure(config SecureConfig) HandlerFunc {
+	return func(c *Context) {
+		c.Set(secureConfigKey, config)
+		config.apply(c)
+	}
+}
+
+// SecureOverride returns a middleware changing the headers set by Secure for the routes
+// using it, e.g. to relax the Content-Security-Policy of an embedded page:
+//
+//	r.GET("/widget", gin.SecureOverride(func(config *gin.SecureConfig) {
+//		config.FrameOptions = "-"
+//		config.ContentSecurityPolicy = "frame-ancestors https://partner.example.com"
+//	}), widget)
+func SecureOverride(override func(config *SecureConfig)) HandlerFunc {
+	return func(c *Context) {
+		var config SecureConfig
+		if value, err := c.Get(secureConfigKey); err == nil {
+			config = value.(SecureConfig)
+		}
+		override(&config)
+		c.Set(secureConfigKey, config)
+		config.apply(c)
+	}
+}
+
+func (config SecureConfig) apply(c *Context) {
+	header := c.Writer.Header()
+	set := func(key, value, def string) {
+		switch value {
+		case "-":
+			header.Del(key)
+		case "":
+			header.Set(key, def)
+		default:
+			header.Set(key, value)
+		}
+	}
+	set("X-Content-Type-Options", config.ContentTypeOptions, "nosniff")
+	set("X-Frame-Options", config.FrameOptions, "DENY")
+	set("Referrer-Policy", config.ReferrerPolicy, "strict-origin-when-cross-origin")
+	set("Content-Security-Policy", config.ContentSecurityPolicy, "default-src 'self'")
+
+	header.Del("Strict-Transport-Security")
+	if config.HSTSMaxAge < 0 || (c.Request.TLS == nil && c.Request.Header.Get("X-Forwarded-Proto") != "https") {
+		return
+	}
+	maxAge := config.HSTSMaxAge
+	if maxAge == 0 {
+		maxAge = 365 * 24 * time.Hour
+	}
+	hsts := "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if config.HSTSIncludeSubdomains {
+		hsts += "; includeSubDomains"
+	}
+	if config.HSTSPreload {
+		hsts += "; preload"
+	}
+	header.Set("Strict-Transport-Security", hsts)
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSecure tests the default security headers, HSTS and the route overrides.
+func TestSecure(t *testing.T) {
+	r := New()
+	r.Use(Secure(SecureConfig{HSTSMaxAge: time.Hour, HSTSIncludeSubdomains: true, FrameOptions: "SAMEORIGIN"}))
+	r.GET("/", func(c *Context) {})
+	r.GET("/widget", SecureOverride(func(config *SecureConfig) {
+		config.FrameOptions = "-"
+		config.ContentSecurityPolicy = "frame-ancestors *"
+	}), func(c *Context) {})
+
+	w := PerformRequest(r, "GET", "/")
+	expected := map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   "default-src 'self'",
+		"Strict-Transport-Security": "",
+	}
+	for key, value := range expected {
+		if w.HeaderMap.Get(key) != value {
+			t.Errorf("%s should be %q, was: %q", key, value, w.HeaderMap.Get(key))
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/widget", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if hsts := w.HeaderMap.Get("Strict-Transport-Security"); hsts != "max-age=3600; includeSubDomains" {
+		t.Errorf("HSTS should be sent over https, was: %q", hsts)
+	}
+	if _, ok := w.HeaderMap["X-Frame-Options"]; ok {
+		t.Errorf("X-Frame-Options should be removed by the override")
+	}
+	if csp := w.HeaderMap.Get("Content-Security-Policy"); csp != "frame-ancestors *" {
+		t.Errorf("Content-Security-Policy should be overridden, was: %q", csp)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SessionKey = "session"
+	flashesKey = "_flashes"
+)
+
+type (
+	// SessionStore keeps the session values. The cookie value identifies a session: a random ID
+	// for server side stores (the memory store, or a Redis implementation for several instances),
+	// or the values themselves for the cookie store.
+	SessionStore interface {
+		// Load returns the values of the session, nil for an unknown or expired session.
+		Load(cookie string) (map[string]interface{}, error)
+		// Save stores the values of the session identified by cookie, empty for a new session,
+		// and returns the cookie value to send.
+		Save(cookie string, values map[string]interface{}, maxAge time.Duration) (string, error)
+		// Delete removes the session.
+		Delete(cookie string) error
+	}
+
+	// SessionOptions are the attributes of the session cookie. MaxAge defaults to 30 days.
+	// The cookie is HttpOnly unless DisableHttpOnly lets the scripts of the page read it.
+	SessionOptions struct {
+		Path            string
+		Domain          string
+		MaxAge          time.Duration
+		Secure          bool
+		DisableHttpOnly bool
+		SameSite        http.SameSite
+	}
+
+	// Session is the session of a request, see c.Session. Changes are kept only once saved.
+	Session struct {
+		name    string
+		store   SessionStore
+		options SessionOptions
+		cookie  string
+		values  map[string]interface{}
+		writer  http.ResponseWriter
+	}
+)
+
+// Sessions returns a middleware loading the session identified by the cookie called name
+// from store. The session is available through c.Session().
+func Sessions(name string, store SessionStore, options SessionOptions) HandlerFunc {
+	if options.Path == "" {
+		options.Path = "/"
+	}
+	if options.MaxAge == 0 {
+		options.MaxAge = 30 * 24 * time.Hour
+	}
+	return func(c *Context) {
+		session := &Session{
+			name:    name,
+			store:   store,
+			options: options,
+			writer:  c.Writer,
+		}
+		if cookie, err := c.Request.Cookie(name); err == nil {
+			values, err := store.Load(cookie.Value)
+			if err != nil {
+				c.ErrorTyped(err, ErrorTypeInternal, "session store")
+			} else if values != nil {
+				session.cookie = cookie.Value
+				session.values = values
+			}
+		}
+		if session.values == nil {
+			session.values = make(map[string]interface{})
+		}
+		c.Set(SessionKey, session)
+	}
+}
+
+// Session returns the session loaded by the Sessions middleware, nil without it.
+func (c *Context) Session() *Session {
+	if session, err := c.Get(SessionKey); err == nil {
+		if s, ok := session.(*Session); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s *Session) Get(key string) interface{} {
+	return s.values[key]
+}
+
+func (s *Session) Set(key string, value interface{}) {
+	s.values[key] = value
+}
+
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+}
+
+// Clear removes every value of the session.
+func (s *Session) Clear() {
+	s.values = make(map[string]interface{})
+}
+
+// AddFlash adds a message kept until it is read by Flashes, typically on the next request.
+func (s *Session) AddFlash(value interface{}) {
+	flashes, _ := s.values[flashesKey].([]interface{})
+	s.values[flashesKey] = append(flashes, value)
+}
+
+// Flashes returns and removes the flash messages. Save the session to persist the removal.
+func (s *Session) Flashes() []interface{} {
+	flashes, _ := s.values[flashesKey].([]interface{})
+	delete(s.values, flashesKey)
+	return flashes
+}
+
+// Save stores the session and sets the session cookie. It must be called before the
+// response body is written.
+func (s *Session) Save() error {
+	cookie, err := s.store.Save(s.cookie, s.values, s.options.MaxAge)
+	if err != nil {
+		return err
+	}
+	s.cookie = cookie
+	s.setCookie(cookie, int(s.options.MaxAge/time.Second))
+	return nil
+}
+
+// Destroy deletes the session from the store and expires the cookie.
+func (s *Session) Destroy() error {
+	s.values = make(map[string]interface{})
+	if s.cookie != "" {
+		if err := s.store.Delete(s.cookie); err != nil {
+			return err
+		}
+	}
+	s.cookie = ""
+	s.setCookie("", -1)
+	return nil
+}
+
+func (s *Session) setCookie(value string, maxAge int) {
+	http.SetCookie(s.writer, &http.Cookie{
+		Name:     s.name,
+		Value:    value,
+		Path:     s.options.Path,
+		Domain:   s.options.Domain,
+		MaxAge:   maxAge,
+		Secure:   s.options.Secure,
+		HttpOnly: !s.options.DisableHttpOnly,
+		SameSite: s.options.SameSite,
+	})
+}
+
+type (
+	memorySessionStore struct {
+		mu        sync.Mutex
+		sessions  map[string]memorySession
+		lastSweep time.Time
+		now       func() time.Time
+	}
+
+	memorySession struct {
+		values  map[string]interface{}
+		expires time.Time
+	}
+)
+
+// NewMemorySessionStore returns a SessionStore keeping the sessions in the process memory,
+// identified by random IDs. Expired sessions are dropped when saving, at most once a minute.
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{sessions: make(map[string]memorySession), now: time.Now}
+}
+
+func (s *memorySessionStore) Load(cookie string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[cookie]
+	if !ok || s.now().After(session.expires) {
+		return nil, nil
+	}
+	return copyValues(session.values), nil
+}
+
+func (s *memorySessionStore) Save(cookie string, values map[string]interface{}, maxAge time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for id, session := range s.sessions {
+			if now.After(session.expires) {
+				delete(s.sessions, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	if cookie == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		cookie = hex.EncodeToString(b)
+	}
+	s.sessions[cookie] = memorySession{values: copyValues(values), expires: now.Add(maxAge)}
+	return cookie, nil
+}
+
+func (s *memorySessionStore) Delete(cookie string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, cookie)
+	return nil
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		cp[k] = v
+	}
+	return cp
+}
+
+type cookieSessionStore struct {
+	key []byte
+}
+
+// NewCookieSessionStore returns a SessionStore keeping the values in the cookie itself, encoded
+// as JSON and signed with key so they can't be tampered with. They are readable by the client,
+// don't store secrets. Values come back as decoded by encoding/json, numbers as float64.
+func NewCookieSessionStore(key []byte) SessionStore {
+	return &cookieSessionStore{key: key}
+}
+
+func (s *cookieSessionStore) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *cookieSessionStore) Load(cookie string) (map[string]interface{}, error) {
+	index := strings.LastIndexByte(cookie, '.')
+	if index < 0 || !hmac.Equal([]byte(cookie[index+1:]), []byte(s.sign(cookie[:index]))) {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie[:index])
+	if err != nil {
+		return nil, nil
+	}
+	var session struct {
+		Values  map[string]interface{} `json:"v"`
+		Expires int64                  `json:"e"`
+	}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, nil
+	}
+	if time.Now().Unix() > session.Expires {
+		return nil, nil
+	}
+	return session.Values, nil
+}
+
+func (s *cookieSessionStore) Save(cookie string, values map[string]interface{}, maxAge time.Duration) (string, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"v": values,
+		"e": time.Now().Add(maxAge).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	cookie = payload + "." + s.sign(payload)
+	if len(cookie) > 4000 {
+		return "", errors.New("session: cookie store values exceed the 4KB cookie size")
+	}
+	return cookie, nil
+}
+
+func (s *cookieSessionStore) Delete(cookie string) error {
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testSessionStore(t *testing.T, store SessionStore) {
+	r := New()
+	r.Use(Sessions("sid", store, SessionOptions{}))
+	r.GET("/login", func(c *Context) {
+		session := c.Session()
+		session.Set("user", "gin")
+		session.AddFlash("welcome")
+		if err := session.Save(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	r.GET("/me", func(c *Context) {
+		session := c.Session()
+		flashes := session.Flashes()
+		session.Save()
+		c.String(200, "%v %v", session.Get("user"), flashes)
+	})
+	r.GET("/logout", func(c *Context) {
+		c.Session().Destroy()
+	})
+
+	perform := func(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	lastCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+		if len(cookies) == 0 {
+			t.Fatal("Session cookie should be set")
+		}
+		return cookies[len(cookies)-1]
+	}
+
+	cookie := lastCookie(perform("/login", nil))
+	if !cookie.HttpOnly || cookie.Name != "sid" {
+		t.Errorf("Unexpected session cookie: %v", cookie)
+	}
+	w := perform("/me", cookie)
+	if w.Body.String() != "gin [welcome]" {
+		t.Errorf("Session should hold the user and the flash, was: %s", w.Body.String())
+	}
+	cookie = lastCookie(w)
+	if w := perform("/me", cookie); w.Body.String() != "gin []" {
+		t.Errorf("Flashes should be read once, was: %s", w.Body.String())
+	}
+	if w := perform("/me", &http.Cookie{Name: "sid", Value: cookie.Value + "x"}); w.Body.String() != "<nil> []" {
+		t.Errorf("Unknown session should be empty, was: %s", w.Body.String())
+	}
+	if cookie := lastCookie(perform("/logout", cookie)); cookie.MaxAge >= 0 {
+		t.Errorf("Session cookie should be expired, was: %v", cookie)
+	}
+}
+
+// TestSessionsMemoryStore tests sessions kept in memory.
+func TestSessionsMemoryStore(t *testing.T) {
+	testSessionStore(t, NewMemorySessionStore())
+}
+
+// TestSessionsCookieStore tests sessions kept in a signed cookie.
+func TestSessionsCookieStore(t *testing.T) {
+	testSessionStore(t, NewCookieSessionStore([]byte("secret")))
+}
+
+// TestSessionWithoutMiddleware tests that c.Session is nil without the middleware.
+func TestSessionWithoutMiddleware(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.String(200, fmt.Sprint(c.Session() == nil))
+	})
+	if w := PerformRequest(r, "GET", "/"); w.Body.String() != "true" {
+		t.Errorf("Session should be nil without the middleware")
+	}
+}
+
+// TestSessionDisableHttpOnly tests that the session cookie can be read by scripts.
+func TestSessionDisableHttpOnly(t *testing.T) {
+	r := New()
+	r.Use(Sessions("sid", NewMemorySessionStore(), SessionOptions{DisableHttpOnly: true}))
+	r.GET("/", func(c *Context) {
+		c.Session().Set("user", "gin")
+		c.Session().Save()
+	})
+	w := PerformRequest(r, "GET", "/")
+	cookies := (&http.Response{Header: w.HeaderMap}).Cookies()
+	if len(cookies) != 1 || cookies[0].HttpOnly {
+		t.Errorf("Session cookie should not be HttpOnly, was: %v", cookies)
+	}
+}
+
+// TestMemorySessionStoreSweep tests that the expired sessions are dropped at most once a minute.
+func TestMemorySessionStoreSweep(t *testing.T) {
+	now := time.Now()
+	store := NewMemorySessionStore().(*memorySessionStore)
+	store.now = func() time.Time { return now }
+
+	store.Save("", nil, time.Second)
+	now = now.Add(2 * time.Second)
+	store.Save("", nil, time.Hour)
+	if len(store.sessions) != 2 {
+		t.Errorf("Sessions should not be swept within a minute, was: %d sessions", len(store.sessions))
+	}
+	now = now.Add(2 * time.Minute)
+	store.Save("", nil, time.Hour)
+	if len(store.sessions) != 2 {
+		t.Errorf("Expired session should be swept, was: %d sessions", len(store.sessions))
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+	"sync"
+	"third/httprouter"
+	"time"
+)
+
+type (
+	// SlowRequest describes a request reported by the SlowRequests middleware.
+	SlowRequest struct {
+		Method  string
+		Route   string
+		Path    string
+		Params  httprouter.Params
+		Latency time.Duration
+		// Stack is the stack of the goroutine handling the request when it went over the
+		// threshold, showing where the time was spent.
+		Stack []byte
+	}
+
+	// SlowRequestConfig configures the SlowRequests middleware.
+	SlowRequestConfig struct {
+		// Threshold is the latency over which requests are slow, one second by default.
+		Threshold time.Duration
+		// Logger receives the slow requests, the standard logger by default.
+		Logger *log.Logger
+		// OnSlow, if set, is also called with the slow requests, e.g. to alert.
+		OnSlow func(c *Context, slow SlowRequest)
+	}
+)
+
+// SlowRequests returns a middleware reporting the requests taking longer than the threshold,
+// with the stack of their goroutine sampled once the threshold is reached. Taking the stack
+// stops the world briefly, so keep the threshold well above the usual latencies.
+func SlowRequests(config SlowRequestConfig) HandlerFunc {
+	if config.Threshold <= 0 {
+		config.Threshold = time.Second
+	}
+	logf := log.Printf
+	if config.Logger != nil {
+		logf = config.Logger.Printf
+	}
+	return func(c *Context) {
+		start := time.Now()
+		id := goroutineID()
+		var mu sync.Mutex
+		var stack []byte
+		timer := time.AfterFunc(config.Threshold, func() {
+			s := goroutineStack(id)
+			mu.Lock()
+			stack = s
+			mu.Unlock()
+		})
+
+		c.Next()
+
+		timer.Stop()
+		latency := time.Since(start)
+		if latency < config.Threshold {
+			return
+		}
+		mu.Lock()
+		slow := SlowRequest{
+			Method:  c.Request.Method,
+			Route:   c.FullPath(),
+			Path:    c.Request.URL.Path,
+			Params:  c.Params,
+			Latency: latency,
+			Stack:   stack,
+		}
+		mu.Unlock()
+		logf("[GIN] slow request: %s %s (%s) %v took %v\n%s", slow.Method, slow.Path, slow.Route, slow.Params, slow.Latency, slow.Stack)
+		if config.OnSlow != nil {
+			config.OnSlow(c, slow)
+		}
+	}
+}
+
+// goroutineID returns the ID of the current goroutine, from the header of its stack.
+func goroutineID() []byte {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	if i := bytes.IndexByte(buf, '['); i > 0 {
+		return buf[:i]
+	}
+	return nil
+}
+
+// goroutineStack returns the stack of the goroutine whose stack header starts with id,
+// e.g. "goroutine 42 ".
+func goroutineStack(id []byte) []byte {
+	if id == nil {
+		return nil
+	}
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, id) {
+			return stack
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func slowTestHandler(c *Context) {
+	time.Sleep(50 * time.Millisecond)
+}
+
+// TestSlowRequests tests that slow requests are reported with the stack of their handler.
+func TestSlowRequests(t *testing.T) {
+	output := new(bytes.Buffer)
+	var reported []SlowRequest
+	r := New()
+	r.Use(SlowRequests(SlowRequestConfig{
+		Threshold: 20 * time.Millisecond,
+		Logger:    log.New(output, "", 0),
+		OnSlow:    func(c *Context, slow SlowRequest) { reported = append(reported, slow) },
+	}))
+	r.GET("/fast", func(c *Context) {})
+	r.GET("/slow/:id", slowTestHandler)
+
+	PerformRequest(r, "GET", "/fast")
+	PerformRequest(r, "GET", "/slow/1")
+
+	if len(reported) != 1 {
+		t.Fatalf("Only the slow request should be reported, was: %d", len(reported))
+	}
+	slow := reported[0]
+	if slow.Route != "/slow/:id" || slow.Params.ByName("id") != "1" || slow.Latency < 50*time.Millisecond {
+		t.Errorf("Slow request should be described, was: %+v", slow)
+	}
+	if !strings.Contains(string(slow.Stack), "slowTestHandler") {
+		t.Errorf("Stack should show where the time was spent, was: %s", slow.Stack)
+	}
+	if !strings.Contains(output.String(), "slow request: GET /slow/1") {
+		t.Errorf("Slow request should be logged, was: %s", output.String())
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type spaConfig struct {
+	prefix   string
+	root     string
+	excludes []string
+}
+
+// ServeSPA serves a single page application built in root under prefix: GET and HEAD requests
+// matching no route get the file of root they name when it exists, root/index.html otherwise,
+// so client side routes survive a reload. Paths under one of the excludes prefixes, e.g. "/api",
+// keep the regular 404 handling. Routes registered on the engine always win.
+// It can be called once per prefix, the longest matching prefix is used.
+func (engine *Engine) ServeSPA(prefix, root string, excludes ...string) {
+	engine.spas = append(engine.spas, spaConfig{
+		prefix:   path.Clean("/" + prefix),
+		root:     root,
+		excludes: excludes,
+	})
+}
+
+// matchSPA returns the application serving urlPath, nil if none does.
+func (engine *Engine) matchSPA(method, urlPath string) *spaConfig {
+	if method != "GET" && method != "HEAD" {
+		return nil
+	}
+	var match *spaConfig
+	for i := range engine.spas {
+		spa := &engine.spas[i]
+		if !hasPathPrefix(urlPath, spa.prefix) {
+			continue
+		}
+		if match == nil || len(spa.prefix) > len(match.prefix) {
+			match = spa
+		}
+	}
+	if match == nil {
+		return nil
+	}
+	for _, exclude := range match.excludes {
+		if hasPathPrefix(urlPath, path.Clean("/"+exclude)) {
+			return nil
+		}
+	}
+	return match
+}
+
+func (spa *spaConfig) serve(c *Context) {
+	rel := path.Clean("/" + strings.TrimPrefix(c.Request.URL.Path, spa.prefix))
+	file := filepath.Join(spa.root, filepath.FromSlash(rel))
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		file = filepath.Join(spa.root, "index.html")
+	}
+	c.File(file)
+}
+
+// hasPathPrefix reports whether urlPath is prefix or below it.
+func hasPathPrefix(urlPath, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+}
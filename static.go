@@ -0,0 +1,157 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig configures StaticWithConfig.
+type StaticConfig struct {
+	// MaxAge is how long the assets that aren't fingerprinted may be cached. When zero they
+	// are sent with "no-cache", so the clients revalidate them with their Last-Modified.
+	MaxAge time.Duration
+	// CacheControl, if set, returns the Cache-Control of a file instead of the default policy.
+	CacheControl func(name string) string
+	// Index serves the index.html file of the directories. Directories are never listed.
+	Index bool
+}
+
+// fingerprintLength is the number of hex digits of the content hash in fingerprinted names.
+const fingerprintLength = 8
+
+// StaticWithConfig serves the files of root like Static, with the Cache-Control of config:
+// fingerprinted files are immutable and cached for a year, HTML files are always revalidated,
+// and the other files are cached for MaxAge. A request for a fingerprinted name, e.g.
+// app.3f2a9c1b.js, is answered with app.js when its content hash starts with 3f2a9c1b; see
+// FingerprintPath to build those names. Files whose name holds a hash computed by a bundler
+// aren't checked, so they follow MaxAge unless config.CacheControl says otherwise.
+func (group *RouterGroup) StaticWithConfig(relativePath, root string, config StaticConfig) {
+	dir := http.Dir(root)
+	var hashes sync.Map
+	handler := func(c *Context) {
+		name := path.Clean("/" + c.Param("filepath"))
+		f, stat, err := openStatic(dir, name, config.Index)
+		fingerprint := false
+		if err != nil {
+			f, stat, err = openFingerprinted(dir, name, &hashes)
+			fingerprint = true
+		}
+		if err != nil {
+			c.AbortWithStatus(404)
+			return
+		}
+		defer f.Close()
+
+		cacheControl := ""
+		if config.CacheControl != nil {
+			cacheControl = config.CacheControl(name)
+		} else if fingerprint {
+			cacheControl = "public, max-age=31536000, immutable"
+		} else if strings.HasSuffix(stat.Name(), ".html") || config.MaxAge <= 0 {
+			cacheControl = "no-cache"
+		} else {
+			cacheControl = "public, max-age=" + strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+		}
+		if cacheControl != "" {
+			c.Writer.Header().Set("Cache-Control", cacheControl)
+		}
+		http.ServeContent(c.Writer, c.Request, stat.Name(), stat.ModTime(), f)
+	}
+	relativePath = path.Join(relativePath, "/*filepath")
+	group.GET(relativePath, handler)
+	group.HEAD(relativePath, handler)
+}
+
+// FingerprintPath returns the URL of the file name of root served under urlPrefix with
+// StaticWithConfig, its content hash inserted before the extension:
+// FingerprintPath("/static", "assets", "js/app.js") returns "/static/js/app.3f2a9c1b.js".
+func FingerprintPath(urlPrefix, root, name string) (string, error) {
+	f, err := http.Dir(root).Open(path.Clean("/" + name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash, err := contentHash(f)
+	if err != nil {
+		return "", err
+	}
+	ext := path.Ext(name)
+	return path.Join("/", urlPrefix, strings.TrimSuffix(name, ext)+"."+hash[:fingerprintLength]+ext), nil
+}
+
+// openStatic opens the file name of dir, or the index.html of the directory name when index
+// is set. Directories aren't served.
+func openStatic(dir http.Dir, name string, index bool) (http.File, os.FileInfo, error) {
+	f, err := dir.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	stat, err := f.Stat()
+	if err == nil && stat.IsDir() {
+		f.Close()
+		if !index {
+			return nil, nil, os.ErrNotExist
+		}
+		return openStatic(dir, path.Join(name, "index.html"), false)
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, stat, nil
+}
+
+// openFingerprinted opens the file of a fingerprinted name, e.g. app.js for app.3f2a9c1b.js,
+// if its content hash matches. The hashes are cached by name and modification time.
+func openFingerprinted(dir http.Dir, name string, hashes *sync.Map) (http.File, os.FileInfo, error) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	dot := strings.LastIndexByte(base, '.')
+	if dot < 0 || len(base)-dot-1 != fingerprintLength {
+		return nil, nil, os.ErrNotExist
+	}
+	fingerprint := base[dot+1:]
+	f, stat, err := openStatic(dir, base[:dot]+ext, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := base[:dot] + ext + "\x00" + stat.ModTime().String()
+	hash, ok := hashes.Load(key)
+	if !ok {
+		h, err := contentHash(f)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		hashes.Store(key, h)
+		hash = h
+	}
+	if !strings.HasPrefix(hash.(string), fingerprint) {
+		f.Close()
+		return nil, nil, os.ErrNotExist
+	}
+	return f, stat, nil
+}
+
+func contentHash(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
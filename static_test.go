@@ -0,0 +1,64 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestStaticWithConfig tests the Cache-Control policies, the fingerprinted names and the index.
+func TestStaticWithConfig(t *testing.T) {
+	dir, err := os.MkdirTemp("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("js"), 0644)
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte("png"), 0644)
+	os.WriteFile(filepath.Join(dir, "vendor.0123456789.js"), []byte("vendor"), 0644)
+	os.WriteFile(filepath.Join(dir, "report-20240101.pdf"), []byte("pdf"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "index.html"), []byte("docs"), 0644)
+
+	r := New()
+	r.StaticWithConfig("/static", dir, StaticConfig{MaxAge: time.Hour, Index: true})
+	appPath, err := FingerprintPath("/static", dir, "app.js")
+	if err != nil || len(appPath) != len("/static/app.12345678.js") {
+		t.Fatalf("Fingerprinted path should be built, was: %s %v", appPath, err)
+	}
+
+	tests := []struct {
+		path, body, cacheControl string
+		code                     int
+	}{
+		{appPath, "js", "public, max-age=31536000, immutable", 200},
+		{"/static/app.00000000.js", "", "", 404},
+		{"/static/app.js", "js", "public, max-age=3600", 200},
+		{"/static/logo.png", "png", "public, max-age=3600", 200},
+		{"/static/vendor.0123456789.js", "vendor", "public, max-age=3600", 200},
+		{"/static/report-20240101.pdf", "pdf", "public, max-age=3600", 200},
+		{"/static/docs/", "docs", "no-cache", 200},
+		{"/static/missing.js", "", "", 404},
+	}
+	for _, test := range tests {
+		w := PerformRequest(r, "GET", test.path)
+		if w.Code != test.code || (test.code == 200 && w.Body.String() != test.body) || w.HeaderMap.Get("Cache-Control") != test.cacheControl {
+			t.Errorf("%s should answer %d %q with %q, was: %d %q with %q", test.path, test.code, test.body, test.cacheControl,
+				w.Code, w.Body.String(), w.HeaderMap.Get("Cache-Control"))
+		}
+	}
+
+	r = New()
+	r.StaticWithConfig("/static", dir, StaticConfig{})
+	if w := PerformRequest(r, "GET", "/static/docs/"); w.Code != 404 {
+		t.Errorf("Directories should not be served without Index, was: %d", w.Code)
+	}
+	if w := PerformRequest(r, "GET", "/static/app.js"); w.HeaderMap.Get("Cache-Control") != "no-cache" {
+		t.Errorf("Assets should be revalidated without MaxAge, was: %q", w.HeaderMap.Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout returns a middleware running the rest of the chain under a deadline. The request
+// context is cancelled once timeout expires and, unless the handler already started streaming,
+// the client is answered right away by fallback, or with 504 Gateway Timeout when nil.
+// Whatever the handler writes afterwards, from its goroutine or another, is discarded and
+// the writes fail with http.ErrHandlerTimeout. The middleware still waits for the handler
+// to return, so handlers should give up when c.Request.Context() is done.
+func Timeout(timeout time.Duration, fallback http.Handler) HandlerFunc {
+	if fallback == nil {
+		fallback = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "Gateway Timeout", http.StatusGatewayTimeout)
+		})
+	}
+	return func(c *Context) {
+		req := c.Request
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		c.Request = req.WithContext(ctx)
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		original := c.Writer
+		tw := &timeoutWriter{w: newBufferedWriter(original), header: cloneHeader(original.Header())}
+		c.Writer = tw
+
+		done := make(chan struct{})
+		var panicked interface{}
+		go func() {
+			defer close(done)
+			defer func() { panicked = recover() }()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+			tw.mu.Lock()
+			tw.finish()
+			tw.mu.Unlock()
+		case <-timer.C:
+			// The writer is closed before cancelling the context, so the handler can't
+			// write once it sees the context done.
+			tw.mu.Lock()
+			tw.timedOut = true
+			if !tw.w.streaming {
+				fallback.ServeHTTP(original, c.Request)
+				original.Flush()
+			}
+			tw.mu.Unlock()
+			cancel()
+			<-done
+			c.Abort()
+		}
+		c.Writer = original
+		c.Request = req
+		if panicked != nil {
+			panic(panicked)
+		}
+	}
+}
+
+// timeoutWriter serializes the writes of the handler with the timeout, after which they fail.
+// It has its own copy of the headers set before it, which replaces the response headers when
+// it is sent.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	w        *bufferedWriter
+	header   http.Header
+	timedOut bool
+}
+
+// finish sends the response written by the handler. It must be called with mu held.
+func (tw *timeoutWriter) finish() {
+	if tw.timedOut || tw.w.streaming {
+		return
+	}
+	tw.copyHeader()
+	tw.w.flush()
+}
+
+func (tw *timeoutWriter) copyHeader() {
+	dst := tw.w.ResponseWriter.Header()
+	for key := range dst {
+		if _, ok := tw.header[key]; !ok {
+			delete(dst, key)
+		}
+	}
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return tw.w.Write(data)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut {
+		tw.w.WriteHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) WriteHeaderNow() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut {
+		tw.w.WriteHeaderNow()
+	}
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.w.Status()
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.w.Size()
+}
+
+func (tw *timeoutWriter) Written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.w.Written()
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if !tw.w.streaming {
+		tw.copyHeader()
+	}
+	tw.w.Flush()
+}
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	tw.w.streaming = true
+	return tw.w.Hijack()
+}
+
+func (tw *timeoutWriter) CloseNotify() <-chan bool {
+	return tw.w.CloseNotify()
+}
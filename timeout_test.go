@@ -0,0 +1,74 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestTimeout tests that slow handlers are answered with 504 and their late writes discarded.
+func TestTimeout(t *testing.T) {
+	lateErr := make(chan error, 1)
+	r := New()
+	r.Use(Timeout(20*time.Millisecond, nil))
+	r.GET("/fast", func(c *Context) {
+		c.Writer.Header().Set("X-Fast", "1")
+		c.String(201, "fast")
+	})
+	r.GET("/slow", func(c *Context) {
+		<-c.Request.Context().Done()
+		_, err := c.Writer.Write([]byte("late"))
+		lateErr <- err
+	})
+
+	w := PerformRequest(r, "GET", "/fast")
+	if w.Code != 201 || w.Body.String() != "fast" || w.HeaderMap.Get("X-Fast") != "1" {
+		t.Errorf("Fast handler should be answered normally, was: %d %s %v", w.Code, w.Body.String(), w.HeaderMap)
+	}
+
+	w = PerformRequest(r, "GET", "/slow")
+	if w.Code != 504 || w.Body.String() != "Gateway Timeout\n" {
+		t.Errorf("Slow handler should be answered with 504, was: %d %s", w.Code, w.Body.String())
+	}
+	if err := <-lateErr; err != http.ErrHandlerTimeout {
+		t.Errorf("Late write should fail with ErrHandlerTimeout, was: %v", err)
+	}
+}
+
+// TestTimeoutPanic tests that panics of the handler reach the outer middlewares.
+func TestTimeoutPanic(t *testing.T) {
+	r := New()
+	r.Use(Recovery(), Timeout(time.Second, nil))
+	r.GET("/", func(c *Context) {
+		panic("oops")
+	})
+
+	if w := PerformRequest(r, "GET", "/"); w.Code != 500 {
+		t.Errorf("Panic should be recovered with 500, was: %d", w.Code)
+	}
+}
+
+// TestTimeoutHeaders tests that the handler sees and can change the headers set before Timeout.
+func TestTimeoutHeaders(t *testing.T) {
+	r := New()
+	r.Use(RequestID(), func(c *Context) {
+		c.Writer.Header().Set("X-Removed", "1")
+	}, Timeout(time.Second, nil))
+	r.GET("/", func(c *Context) {
+		c.Writer.Header().Del("X-Removed")
+		c.String(200, c.Writer.Header().Get(HeaderXRequestID))
+	})
+
+	w := PerformRequest(r, "GET", "/")
+	id := w.HeaderMap.Get(HeaderXRequestID)
+	if id == "" || w.Body.String() != id {
+		t.Errorf("Handler should see the request ID set before Timeout, was: %q %q", w.Body.String(), id)
+	}
+	if w.HeaderMap.Get("X-Removed") != "" {
+		t.Errorf("Header removed by the handler should not be sent, was: %v", w.HeaderMap)
+	}
+}
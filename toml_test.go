@@ -0,0 +1,49 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build toml
+// +build toml
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestBindingTOML tests binding a TOML body, with its defaults and validation.
+func TestBindingTOML(t *testing.T) {
+	type config struct {
+		Name string `toml:"name" binding:"required"`
+		Port int    `toml:"port" default:"8080"`
+	}
+
+	var obj config
+	r := New()
+	r.POST("/config", func(c *Context) {
+		obj = config{}
+		if c.Bind(&obj) {
+			c.String(200, "ok")
+		}
+	})
+
+	perform := func(body string) int {
+		req, _ := http.NewRequest("POST", "/config", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", MIMETOML)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := perform("name = \"gin\"\n"); code != 200 || obj.Name != "gin" || obj.Port != 8080 {
+		t.Errorf("TOML should be bound with the defaults, was: %d %+v", code, obj)
+	}
+	if code := perform("name = \"gin\"\nport = 9090\n"); code != 200 || obj.Port != 9090 {
+		t.Errorf("TOML values should override the defaults, was: %d %+v", code, obj)
+	}
+	if code := perform("port = 9090\n"); code != 400 {
+		t.Errorf("Invalid TOML config should fail with 400, was: %d", code)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const SpanKey = "span"
+
+type (
+	// SpanContext identifies a span in a W3C trace context.
+	SpanContext struct {
+		TraceID [16]byte
+		SpanID  [8]byte
+		Sampled bool
+	}
+
+	// Span is a server span started by a Tracer.
+	Span interface {
+		SpanContext() SpanContext
+		// SetStatus records the HTTP status of the response.
+		SetStatus(code int)
+		RecordError(err error)
+		End()
+	}
+
+	// Tracer starts the server spans of the Tracing middleware. An OpenTelemetry Tracer is
+	// adapted by starting a span with trace.ContextWithRemoteSpanContext(ctx, remote) as
+	// parent and wrapping it to implement Span.
+	Tracer interface {
+		// Start starts the span called name, child of remote when it is valid. The returned
+		// context carries the span for the handlers.
+		Start(ctx context.Context, name string, remote SpanContext) (context.Context, Span)
+	}
+
+	spanContextKey struct{}
+
+	propagationSpan struct {
+		sc SpanContext
+	}
+)
+
+// Tracing returns a middleware starting a server span named after the method and the route
+// template of each request, e.g. "GET /users/:id", with the traceparent header of the request
+// as parent. The span is ended with the status of the response and the errors of c.Errors.
+// The span is available with c.Span(), and its SpanContext in the context of the request
+// (see SpanContextFromContext and SetTraceparent) to propagate the trace downstream.
+// A nil tracer only propagates the trace context, without recording anything.
+func Tracing(tracer Tracer) HandlerFunc {
+	return func(c *Context) {
+		remote, _ := ParseTraceparent(c.Request.Header.Get("traceparent"))
+		name := c.Request.Method
+		if path := c.FullPath(); path != "" {
+			name += " " + path
+		}
+		ctx := c.Request.Context()
+		var span Span
+		if tracer != nil {
+			ctx, span = tracer.Start(ctx, name, remote)
+		} else {
+			span = newPropagationSpan(remote)
+		}
+		c.Request = c.Request.WithContext(context.WithValue(ctx, spanContextKey{}, span.SpanContext()))
+		c.Set(SpanKey, span)
+		defer span.End()
+
+		c.Next()
+
+		for _, err := range c.Errors {
+			span.RecordError(err)
+		}
+		span.SetStatus(c.Writer.Status())
+	}
+}
+
+// Span returns the span started by the Tracing middleware, nil without it.
+func (c *Context) Span() Span {
+	if span, err := c.Get(SpanKey); err == nil {
+		if s, ok := span.(Span); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// ParseTraceparent parses a W3C traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(header string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, false
+	}
+	version, err1 := hex.DecodeString(parts[0])
+	traceID, err2 := hex.DecodeString(parts[1])
+	spanID, err3 := hex.DecodeString(parts[2])
+	flags, err4 := hex.DecodeString(parts[3])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil ||
+		len(version) != 1 || len(traceID) != 16 || len(spanID) != 8 || len(flags) != 1 {
+		return sc, false
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// IsValid reports whether the trace and span IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent returns the W3C traceparent header of the span.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// SpanContextFromContext returns the SpanContext set by the Tracing middleware.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// SetTraceparent sets the traceparent header of an outgoing request from the SpanContext
+// of ctx, if any, so the trace continues in the service called.
+func SetTraceparent(ctx context.Context, header http.Header) {
+	if sc, ok := SpanContextFromContext(ctx); ok && sc.IsValid() {
+		header.Set("traceparent", sc.Traceparent())
+	}
+}
+
+// newPropagationSpan returns a span continuing the trace of remote, or a new sampled trace.
+func newPropagationSpan(remote SpanContext) *propagationSpan {
+	sc := remote
+	if !remote.IsValid() {
+		rand.Read(sc.TraceID[:])
+		sc.Sampled = true
+	}
+	rand.Read(sc.SpanID[:])
+	return &propagationSpan{sc}
+}
+
+func (s *propagationSpan) SpanContext() SpanContext { return s.sc }
+func (s *propagationSpan) SetStatus(code int)       {}
+func (s *propagationSpan) RecordError(err error)    {}
+func (s *propagationSpan) End()                     {}
@@ -0,0 +1,87 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testTracer struct {
+	name   string
+	remote SpanContext
+	span   *testSpan
+}
+
+type testSpan struct {
+	propagationSpan
+	status int
+	errs   []error
+	ended  bool
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, remote SpanContext) (context.Context, Span) {
+	t.name, t.remote = name, remote
+	t.span = &testSpan{propagationSpan: *newPropagationSpan(remote)}
+	return ctx, t.span
+}
+
+func (s *testSpan) SetStatus(code int)    { s.status = code }
+func (s *testSpan) RecordError(err error) { s.errs = append(s.errs, err) }
+func (s *testSpan) End()                  { s.ended = true }
+
+// TestTracing tests that spans continue the trace of the request and record the response.
+func TestTracing(t *testing.T) {
+	tracer := &testTracer{}
+	var outgoing http.Header
+	r := New()
+	r.Use(Tracing(tracer))
+	r.GET("/users/:id", func(c *Context) {
+		outgoing = http.Header{}
+		SetTraceparent(c.Request.Context(), outgoing)
+		c.Error(errors.New("lookup failed"), nil)
+		c.String(502, "")
+	})
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if tracer.name != "GET /users/:id" {
+		t.Errorf("Span should be named after the route, was: %q", tracer.name)
+	}
+	if tracer.remote.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Remote parent should be parsed, was: %s", tracer.remote.Traceparent())
+	}
+	span := tracer.span
+	if !span.ended || span.status != 502 || len(span.errs) != 1 {
+		t.Errorf("Span should record the response, was: %+v", span)
+	}
+	if outgoing.Get("traceparent") != span.SpanContext().Traceparent() {
+		t.Errorf("Traceparent should be propagated, was: %q", outgoing.Get("traceparent"))
+	}
+}
+
+// TestParseTraceparent tests the validation of traceparent headers.
+func TestParseTraceparent(t *testing.T) {
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, header := range invalid {
+		if _, ok := ParseTraceparent(header); ok {
+			t.Errorf("%q should be invalid", header)
+		}
+	}
+	if sc, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); !ok || sc.Sampled {
+		t.Errorf("Future versions should be parsed")
+	}
+}
@@ -6,10 +6,14 @@ package gin
 
 import (
 	"encoding/xml"
+	"fmt"
 	"net/http"
 	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type H map[string]interface{}
@@ -38,6 +42,19 @@ func (h H) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	return nil
 }
 
+// bodyAllowedForStatus reports whether a response with the given status may have a body (RFC 7230 3.3).
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == 204:
+		return false
+	case status == 304:
+		return false
+	}
+	return true
+}
+
 func filterFlags(content string) string {
 	for i, char := range content {
 		if char == ' ' || char == ';' {
@@ -57,17 +74,125 @@ func chooseData(custom, wildcard interface{}) interface{} {
 	return custom
 }
 
+// mediaRange is a range of an Accept header with its q-value.
+type mediaRange struct {
+	value string
+	q     float64
+}
+
+// parseAccept returns the media ranges of an Accept header ordered by their q-value.
+// Ranges with q=0 are dropped. Ties go to the most specific range, "text/html" before
+// "text/*" before "*/*", then keep the order sent by the client.
 func parseAccept(accept string) []string {
+	ranges := parseMediaRanges(accept)
+	accepted := ranges[:0]
+	for _, r := range ranges {
+		if r.q > 0 {
+			accepted = append(accepted, r)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		if accepted[i].q != accepted[j].q {
+			return accepted[i].q > accepted[j].q
+		}
+		return rangeSpecificity(accepted[i].value) > rangeSpecificity(accepted[j].value)
+	})
+	result := make([]string, len(accepted))
+	for i, r := range accepted {
+		result[i] = r.value
+	}
+	return result
+}
+
+// rangeSpecificity ranks the full wildcards under the "type/*" ones under the exact values.
+func rangeSpecificity(value string) int {
+	switch {
+	case value == "*" || value == "*/*":
+		return 0
+	case strings.HasSuffix(value, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// parseMediaRanges returns the media ranges of an Accept header in the order sent by the
+// client, including the ones refused with q=0.
+func parseMediaRanges(accept string) []mediaRange {
 	parts := strings.Split(accept, ",")
-	for i, part := range parts {
-		index := strings.IndexByte(part, ';')
-		if index >= 0 {
+	ranges := make([]mediaRange, 0, len(parts))
+	for _, part := range parts {
+		q := 1.0
+		if index := strings.IndexByte(part, ';'); index >= 0 {
+			for _, param := range strings.Split(part[index+1:], ";") {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						q = v
+					}
+				}
+			}
 			part = part[0:index]
 		}
 		part = strings.TrimSpace(part)
-		parts[i] = part
+		if len(part) == 0 {
+			continue
+		}
+		ranges = append(ranges, mediaRange{part, q})
+	}
+	return ranges
+}
+
+// matchMediaRange reports whether the offered MIME type is covered by the accepted
+// media range, which may use the "*/*" or "type/*" wildcards.
+func matchMediaRange(accepted, offered string) bool {
+	if accepted == offered || accepted == "*/*" {
+		return true
+	}
+	if strings.HasSuffix(accepted, "/*") {
+		return strings.HasPrefix(offered, accepted[:len(accepted)-1])
+	}
+	return false
+}
+
+// contentDisposition builds a Content-Disposition header value (RFC 6266).
+// ASCII names use the plain quoted form. Any other name is sent as an RFC 5987
+// encoded filename* parameter, after a quoted filename fallback where the
+// non-ASCII characters are replaced by '_' for the clients ignoring filename*.
+func contentDisposition(disposition, filename string) string {
+	var fallback, encoded strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fallback.WriteByte('_')
+		case r < utf8.RuneSelf:
+			fallback.WriteRune(r)
+		default:
+			fallback.WriteByte('_')
+			ascii = false
+		}
 	}
-	return parts
+	header := disposition + `; filename="` + fallback.String() + `"`
+	if ascii {
+		return header
+	}
+	for _, b := range []byte(filename) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return header + "; filename*=UTF-8''" + encoded.String()
+}
+
+// isAttrChar reports whether b can be left unencoded in an RFC 5987 value.
+func isAttrChar(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+		strings.IndexByte("!#$&+-.^_`|~", b) >= 0
 }
 
 func lastChar(str string) uint8 {
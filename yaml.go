@@ -0,0 +1,17 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build yaml
+// +build yaml
+
+package gin
+
+import "third/gin/render"
+
+// Serializes the given struct as YAML into the response body.
+// It also sets the Content-Type as "application/x-yaml".
+// It is only available when building with the yaml tag, which needs third/yaml.
+func (c *Context) YAML(code int, obj interface{}) {
+	c.Render(code, render.YAML, obj)
+}
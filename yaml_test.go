@@ -0,0 +1,67 @@
+// Copyright 2014 Manu Martinez-Almeida.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build yaml
+// +build yaml
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"third/yaml"
+)
+
+// TestYAML tests binding a YAML body and rendering YAML.
+func TestYAML(t *testing.T) {
+	type user struct {
+		Name string `yaml:"name" binding:"required"`
+		Age  int    `yaml:"age"`
+	}
+
+	r := New()
+	r.POST("/user", func(c *Context) {
+		var obj user
+		if c.Bind(&obj) {
+			c.YAML(200, obj)
+		}
+	})
+
+	for _, contentType := range []string{MIMEYAML, MIMEYAML2} {
+		req, _ := http.NewRequest("POST", "/user", bytes.NewBufferString("name: gin\nage: 3\n"))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != 200 || w.HeaderMap.Get("Content-Type") != "application/x-yaml; charset=utf-8" {
+			t.Fatalf("%s: YAML should be bound and rendered, was: %d %v", contentType, w.Code, w.HeaderMap)
+		}
+		var obj user
+		if err := yaml.Unmarshal(w.Body.Bytes(), &obj); err != nil || obj.Name != "gin" || obj.Age != 3 {
+			t.Errorf("%s: Response should be the YAML of the user, was: %s", contentType, w.Body.String())
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "/user", bytes.NewBufferString("age: 3\n"))
+	req.Header.Set("Content-Type", MIMEYAML)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 400 {
+		t.Errorf("Invalid YAML user should fail with 400, was: %d", w.Code)
+	}
+}
+
+// TestYAMLRenderError tests that a YAML marshaling error leaves the response to the error handling.
+func TestYAMLRenderError(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.YAML(200, make(chan int))
+	})
+
+	w := PerformRequest(r, "GET", "/")
+	if w.Code != 500 || w.HeaderMap.Get("Content-Type") != "" {
+		t.Errorf("Failing YAML should be answered with 500, was: %d %v", w.Code, w.HeaderMap)
+	}
+}